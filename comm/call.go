@@ -0,0 +1,195 @@
+package comm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/UniversityRadioYork/bifrost-go/core"
+	"github.com/UniversityRadioYork/bifrost-go/message"
+)
+
+// File comm/call.go contains tag-correlated request/response calls for Clients.
+
+// ErrClientClosed is the error returned when using a Client that has stopped.
+var ErrClientClosed = errors.New("client closed")
+
+// ErrDisconnected is the error returned by Do when a reconnecting Client loses its connection before the request
+// is answered.
+var ErrDisconnected = errors.New("disconnected before response")
+
+// Request is a Messager for an ad-hoc request, for use with Client.Do.
+type Request struct {
+	// Word is the request word.
+	Word string
+	// Args holds the request arguments.
+	Args []string
+}
+
+// Message converts a Request into a message with tag tag.
+func (r Request) Message(tag string) *message.Message {
+	return message.New(tag, r.Word).AddArgs(r.Args...)
+}
+
+// call is a request in progress through Client.Do.
+type call struct {
+	// resps receives each response to the request; it closes if the call fails.
+	resps chan message.Message
+	// abandoned closes when Do stops waiting for responses.
+	abandoned chan struct{}
+	// err is the reason the call failed; it is set before resps closes.
+	err error
+}
+
+// Do sends req to the server, tagged with a fresh tag from the client's TagSource,
+// then collects every response with that tag up to and including the ACK that ends it.
+//
+// It returns the responses and, if the ACK isn't OK, a core.AckError; it can also fail if ctx is done before the ACK
+// arrives, or if the client stops or loses its connection.
+// Many Do calls can be in progress at once over the same client.
+func (c *Client) Do(ctx context.Context, req Messager) ([]*message.Message, error) {
+	tag, err := c.NewTag()
+	if err != nil {
+		return nil, err
+	}
+	cl, err := c.startCall(tag)
+	if err != nil {
+		return nil, err
+	}
+	defer c.endCall(tag, cl)
+
+	if !c.Endpoint.Send(ctx, *req.Message(tag)) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrClientClosed
+	}
+
+	var resps []*message.Message
+	for {
+		select {
+		case m, ok := <-cl.resps:
+			if !ok {
+				return resps, cl.err
+			}
+			resps = append(resps, &m)
+			if m.Word() == core.RsAck {
+				return resps, ackError(&m)
+			}
+		case <-ctx.Done():
+			return resps, ctx.Err()
+		}
+	}
+}
+
+// ackError gets the error, if any, represented by the ACK message m.
+func ackError(m *message.Message) error {
+	ack, err := core.ParseAckResponse(m)
+	if err != nil {
+		return err
+	}
+	return ack.Err()
+}
+
+// startCall registers a new call with tag tag.
+func (c *Client) startCall(tag string) (*call, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.calls == nil {
+		return nil, ErrClientClosed
+	}
+	if _, ok := c.calls[tag]; ok {
+		return nil, fmt.Errorf("tag %q already in use", tag)
+	}
+	cl := &call{resps: make(chan message.Message), abandoned: make(chan struct{})}
+	c.calls[tag] = cl
+	return cl, nil
+}
+
+// endCall unregisters the call cl with tag tag.
+func (c *Client) endCall(tag string, cl *call) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	close(cl.abandoned)
+	if c.calls != nil {
+		delete(c.calls, tag)
+	}
+}
+
+// callFor gets the call for messages with tag tag, or nil if there isn't one.
+func (c *Client) callFor(tag string) *call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[tag]
+}
+
+// dispatch routes messages from in to out, as in route, then stops the client.
+func (c *Client) dispatch(ctx context.Context, in <-chan message.Message, out chan<- message.Message) {
+	defer c.stop(out)
+	c.route(ctx, in, out)
+}
+
+// route forwards messages from in to the calls they answer or the Subscriptions they match,
+// or, failing that, to out, dropping them if out is full.
+// It runs until ctx is done or in closes.
+func (c *Client) route(ctx context.Context, in <-chan message.Message, out chan<- message.Message) {
+	for {
+		var (
+			m  message.Message
+			ok bool
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok = <-in:
+			if !ok {
+				return
+			}
+		}
+
+		if cl := c.callFor(m.Tag()); cl != nil {
+			select {
+			case cl.resps <- m:
+			case <-cl.abandoned:
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		if m.Tag() == message.TagBcast && c.publish(&m) {
+			continue
+		}
+
+		// Unread messages mustn't hold up responses to calls.
+		select {
+		case out <- m:
+		default:
+			atomic.AddUint64(&c.rxDropped, 1)
+		}
+	}
+}
+
+// stop closes out, every call in progress, and every Subscription, and stops the client accepting new calls.
+func (c *Client) stop(out chan<- message.Message) {
+	close(out)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failCalls(ErrClientClosed)
+	c.calls = nil
+	c.closeSubs()
+	close(c.done)
+}
+
+// failCalls makes every call in progress fail with err.
+// The client's mutex must be held.
+func (c *Client) failCalls(err error) {
+	for tag, cl := range c.calls {
+		cl.err = err
+		close(cl.resps)
+		delete(c.calls, tag)
+	}
+}
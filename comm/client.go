@@ -2,9 +2,11 @@ package comm
 
 import (
 	"context"
+	"errors"
 	"net"
+	"sync"
+	"sync/atomic"
 
-	"github.com/UniversityRadioYork/bifrost-go/core"
 	"github.com/UniversityRadioYork/bifrost-go/message"
 )
 
@@ -17,13 +19,53 @@ type Client struct {
 	Role string
 
 	// Endpoint is the raw message-based endpoint that can be used to interact with this client's server.
+	// Its Rx channel receives every message from the server except responses to requests made with Do,
+	// and broadcasts matching a Subscription, and closes when the client stops.
+	// Rx has a buffer of ClientRxBuffer messages; if it is full, further messages are dropped, and counted by
+	// RxDropped, so that an unread Rx can't stall Do calls.
 	Endpoint Endpoint
 
 	// ServerIo represents the connection to the external server.
 	ServerIo IoEndpoint
+
+	// Tags is the source of tags for requests made by this client.
+	// If nil, message.DefaultTagSource is used.
+	// Use a message.CounterTagSource for compact, deterministic tags.
+	Tags message.TagSource
+
+	// done closes when the client stops.
+	done chan struct{}
+	// rxDropped is the number of messages dropped because Endpoint.Rx was full.
+	rxDropped uint64
+
+	// mu guards calls and subs.
+	mu sync.Mutex
+	// calls maps the tag of each request in progress through Do to its call; it is nil once the client stops.
+	calls map[string]*call
+	// subs is the set of open Subscriptions.
+	subs map[*Subscription]struct{}
+}
+
+// ClientRxBuffer is the buffer size of a Client's Endpoint.Rx channel.
+const ClientRxBuffer = 64
+
+// RxDropped gets the number of messages this client has dropped because its Endpoint.Rx channel was full.
+func (c *Client) RxDropped() uint64 {
+	return atomic.LoadUint64(&c.rxDropped)
+}
+
+// NewTag generates a tag for a request made by this client, using its TagSource.
+func (c *Client) NewTag() (string, error) {
+	if c.Tags == nil {
+		return message.NewTag()
+	}
+	return c.Tags.NewTag()
 }
 
 // Dial connects to a Bifrost server at address, and, if successful, constructs a new ExternalService over it.
+//
+// Errors on the connection go to errCh, which closes once the connection has closed.
+// The client stops when ctx is done, or when the server hangs up.
 func Dial(ctx context.Context, address string, errCh chan<- error) (c *Client, err error) {
 	conn, err := net.Dial("tcp", address)
 	if err != nil {
@@ -32,59 +74,71 @@ func Dial(ctx context.Context, address string, errCh chan<- error) (c *Client, e
 
 	cliEnd, srvEnd := NewEndpointPair()
 	ioEnd := IoEndpoint{Endpoint: srvEnd, Io: conn}
-	ioEnd.Run(ctx, errCh)
-	return NewClient(ctx, cliEnd, ioEnd)
-}
 
-// NewClient tries to spin up a Client connected to a Bifrost server through serverIo.
-// It expects the endpoint to be running its loops.
-func NewClient(ctx context.Context, cliEnd *Endpoint, serverIo IoEndpoint) (*Client, error) {
-	serverVer, role, err := handshake(ctx, cliEnd)
-	if err != nil {
+	cctx, cancel := context.WithCancel(ctx)
+	// Closing the connection unblocks the IoEndpoint's reader when we hang up.
+	go func() {
+		<-cctx.Done()
+		_ = conn.Close()
+	}()
+
+	ioErrCh := make(chan error)
+	go ioEnd.Run(cctx, ioErrCh)
+	go relayErrors(ctx, cancel, &ioEnd, ioErrCh, errCh)
+
+	if c, err = NewClient(cctx, cliEnd, ioEnd); err != nil {
+		cancel()
 		return nil, err
 	}
-
-	c := &Client{ServerVer: serverVer, Role: role, ServerIo: serverIo}
 	return c, nil
 }
 
-// handshake performs the Bifrost handshake with whichever Bifrost service is on the other end of cliEnd.
-func handshake(ctx context.Context, cliEnd *Endpoint) (serverVer, role string, err error) {
-	// TODO(@MattWindsor91): make this more symmetric with the way it's done on the client side
-	if serverVer, err = recvOhai(ctx, cliEnd); err != nil {
-		return "", "", err
-	}
-	if role, err = recvIama(ctx, cliEnd); err != nil {
-		return "", "", err
+// relayErrors passes errors from the IoEndpoint ioEnd's error channel in to out until in closes, then closes out.
+// When the server hangs up, it cancels the connection and closes ioEnd, which closes the client's Rx channel.
+func relayErrors(ctx context.Context, cancel context.CancelFunc, ioEnd *IoEndpoint, in <-chan error, out chan<- error) {
+	closed := false
+	closeIo := func() {
+		if !closed {
+			closed = true
+			_ = ioEnd.Close()
+		}
 	}
-	return serverVer, role, nil
-}
 
-func recvOhai(ctx context.Context, cliEnd *Endpoint) (serverVer string, err error) {
-	var (
-		ohaiMsg *message.Message
-		ohai    *core.OhaiResponse
-	)
-	if ohaiMsg, err = cliEnd.Recv(ctx); err != nil {
-		return "", err
-	}
-	if ohai, err = core.ParseOhaiResponse(ohaiMsg); err != nil {
-		return "", err
+	for err := range in {
+		// The IoEndpoint only hangs up once its transmitter loop has finished sending on the client's Rx channel.
+		if errors.Is(err, HungUpError) {
+			cancel()
+			closeIo()
+		}
+		select {
+		case out <- err:
+		case <-ctx.Done():
+		}
 	}
-	// TODO(@MattWindsor91): check protocol version
-	return ohai.ServerVer, nil
+	cancel()
+	closeIo()
+	close(out)
 }
 
-func recvIama(ctx context.Context, cliEnd *Endpoint) (role string, err error) {
-	var (
-		iamaMsg *message.Message
-		iama    *core.IamaResponse
-	)
-	if iamaMsg, err = cliEnd.Recv(ctx); err != nil {
-		return "", err
+// NewClient tries to spin up a Client connected to a Bifrost server through serverIo.
+// It expects the endpoint to be running its loops.
+// The client stops when ctx is done, or cliEnd's Rx channel closes.
+func NewClient(ctx context.Context, cliEnd *Endpoint, serverIo IoEndpoint) (*Client, error) {
+	serverVer, role, err := handshake(ctx, cliEnd)
+	if err != nil {
+		return nil, err
 	}
-	if iama, err = core.ParseIamaResponse(iamaMsg); err != nil {
-		return "", err
+
+	rx := make(chan message.Message, ClientRxBuffer)
+	done := make(chan struct{})
+	c := &Client{
+		ServerVer: serverVer,
+		Role:      role,
+		Endpoint:  Endpoint{Rx: rx, Tx: cliEnd.Tx, Done: done},
+		ServerIo:  serverIo,
+		done:      done,
+		calls:     make(map[string]*call),
 	}
-	return iama.Role, nil
+	go c.dispatch(ctx, cliEnd.Rx, rx)
+	return c, nil
 }
@@ -0,0 +1,270 @@
+package comm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/UniversityRadioYork/bifrost-go/core"
+	"github.com/UniversityRadioYork/bifrost-go/message"
+	"github.com/UniversityRadioYork/bifrost-go/message/messagetest"
+)
+
+// newTestClient greets a Client over a fresh endpoint pair, returning it and the server's end of the pair.
+func newTestClient(ctx context.Context, t *testing.T) (*Client, *Endpoint) {
+	t.Helper()
+
+	cli, srv := NewEndpointPair()
+	go func() { _ = Greet(ctx, srv, "test-1.0.0", "player/file") }()
+
+	c, err := NewClient(ctx, cli, IoEndpoint{})
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+	return c, srv
+}
+
+// TestClient_Do checks that concurrent Do calls get the responses with their tags, even when the server answers them
+// out of order, and that other messages go to the client's Endpoint.
+func TestClient_Do(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, srv := newTestClient(ctx, t)
+	c.Tags = message.NewCounterTagSource("t")
+
+	bcast := message.New(message.TagBcast, "STATE").AddArgs("playing")
+	go func() {
+		// Wait for both requests, then answer them in reverse order, with a broadcast in between.
+		var reqs []*message.Message
+		for len(reqs) < 2 {
+			m, err := srv.Recv(ctx)
+			if err != nil {
+				return
+			}
+			reqs = append(reqs, m)
+		}
+		for i := len(reqs) - 1; 0 <= i; i-- {
+			r := reqs[i]
+			srv.Send(ctx, *message.New(r.Tag(), "ECHO").AddArgs(r.Word()))
+			srv.Send(ctx, *bcast)
+			srv.Send(ctx, *core.AckOk.Message(r.Tag()))
+		}
+	}()
+
+	type result struct {
+		word  string
+		resps []*message.Message
+		err   error
+	}
+	results := make(chan result)
+	for _, w := range []string{"PLAY", "STOP"} {
+		go func(w string) {
+			resps, err := c.Do(ctx, Request{Word: w})
+			results <- result{w, resps, err}
+		}(w)
+	}
+
+	for i := 0; i < 2; i++ {
+		var r result
+		select {
+		case r = <-results:
+		case <-c.Endpoint.Rx:
+			// Broadcast; checked below.
+			i--
+			continue
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for Do")
+		}
+
+		if r.err != nil {
+			t.Errorf("Do(%s) error: %v", r.word, r.err)
+			continue
+		}
+		if len(r.resps) != 2 {
+			t.Errorf("Do(%s) got %d responses, want 2", r.word, len(r.resps))
+			continue
+		}
+		tag := r.resps[0].Tag()
+		messagetest.AssertMessagesEqual(t, r.word, r.resps[0], message.New(tag, "ECHO").AddArgs(r.word))
+		messagetest.AssertMessagesEqual(t, r.word, r.resps[1], core.AckOk.Message(tag))
+	}
+}
+
+// TestClient_Do_broadcast checks that messages not answering a Do call reach the client's Endpoint.
+func TestClient_Do_broadcast(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, srv := newTestClient(ctx, t)
+	bcast := message.New(message.TagBcast, "STATE").AddArgs("playing")
+	go srv.Send(ctx, *bcast)
+
+	messagetest.AssertReceive(t, "broadcast", c.Endpoint.Rx, time.Second, bcast)
+}
+
+// TestClient_Do_what checks that Do reports a non-OK ACK as an AckError.
+func TestClient_Do_what(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, srv := newTestClient(ctx, t)
+	go func() {
+		if m, err := srv.Recv(ctx); err == nil {
+			srv.Send(ctx, *core.AckResponse{Status: core.StatusWhat, Description: "unknown word"}.Message(m.Tag()))
+		}
+	}()
+
+	resps, err := c.Do(ctx, Request{Word: "FROB", Args: []string{"x"}})
+	var ae core.AckError
+	if !errors.As(err, &ae) {
+		t.Fatalf("Do error %v, want an AckError", err)
+	}
+	if ae.Status != core.StatusWhat || ae.Description != "unknown word" {
+		t.Errorf("got AckError %v, want WHAT: unknown word", ae)
+	}
+	if len(resps) != 1 {
+		t.Errorf("got %d responses, want 1", len(resps))
+	}
+}
+
+// TestClient_Do_cancelled checks that Do stops waiting when its context is cancelled.
+func TestClient_Do_cancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, srv := newTestClient(ctx, t)
+	// Swallow the request, but never answer it.
+	go srv.Recv(ctx)
+
+	dctx, dcancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer dcancel()
+	if _, err := c.Do(dctx, Request{Word: "PLAY"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do error %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// TestClient_Do_closed checks that Do fails with ErrClientClosed when the client stops.
+func TestClient_Do_closed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, srv := newTestClient(ctx, t)
+	go func() {
+		// Take the request, then hang up.
+		if _, err := srv.Recv(ctx); err == nil {
+			close(srv.Tx)
+		}
+	}()
+
+	if _, err := c.Do(ctx, Request{Word: "PLAY"}); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Do error %v, want %v", err, ErrClientClosed)
+	}
+	if _, err := c.Do(ctx, Request{Word: "PLAY"}); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Do after close error %v, want %v", err, ErrClientClosed)
+	}
+}
+
+// TestDial_hangUp checks that a Dialled client stops, failing calls in progress, when the server hangs up.
+func TestDial_hangUp(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s := &Server{
+		ServerVer: "test-1.0.0",
+		Role:      "player/file",
+		OnConnect: func(c *ServerConn) {
+			// Take the request, then hang up without answering it.
+			<-c.Endpoint.Rx
+			c.Close()
+		},
+	}
+	addr, stop := startTestServer(t, s)
+	defer func() { _ = stop() }()
+
+	errCh := make(chan error)
+	c, err := Dial(ctx, addr, errCh)
+	if err != nil {
+		t.Fatalf("Dial error: %v", err)
+	}
+
+	hungUp := make(chan bool, 1)
+	go func() {
+		h := false
+		for err := range errCh {
+			h = h || errors.Is(err, HungUpError)
+		}
+		hungUp <- h
+	}()
+
+	if _, err := c.Do(ctx, Request{Word: "PLAY"}); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Do error %v, want %v", err, ErrClientClosed)
+	}
+	if _, err := c.Do(ctx, Request{Word: "PLAY"}); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Do after hangup error %v, want %v", err, ErrClientClosed)
+	}
+	if _, ok := <-c.Endpoint.Rx; ok {
+		t.Error("client Endpoint still open after hangup")
+	}
+
+	select {
+	case h := <-hungUp:
+		if !h {
+			t.Error("error channel closed without reporting a hangup")
+		}
+	case <-ctx.Done():
+		t.Fatal("error channel not closed after hangup")
+	}
+}
+
+// TestClient_Do_closedWhileSending checks that Do stops trying to send its request when the client stops.
+func TestClient_Do_closedWhileSending(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Nothing reads requests, so Do blocks sending until the client stops.
+	c, srv := newTestClient(ctx, t)
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := c.Do(context.Background(), Request{Word: "PLAY"})
+		errCh <- err
+	}()
+	close(srv.Tx)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, ErrClientClosed) {
+			t.Errorf("Do error %v, want %v", err, ErrClientClosed)
+		}
+	case <-ctx.Done():
+		t.Fatal("Do still sending after client stopped")
+	}
+}
+
+// TestClient_Do_unreadRx checks that messages nobody reads from the client's Endpoint don't stall Do.
+func TestClient_Do_unreadRx(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	const extra = 10
+	c, srv := newTestClient(ctx, t)
+	go func() {
+		m, err := srv.Recv(ctx)
+		if err != nil {
+			return
+		}
+		for i := 0; i < ClientRxBuffer+extra; i++ {
+			srv.Send(ctx, *message.New(message.TagBcast, "TIME").AddInt(i))
+		}
+		srv.Send(ctx, *core.AckOk.Message(m.Tag()))
+	}()
+
+	if _, err := c.Do(ctx, Request{Word: "PLAY"}); err != nil {
+		t.Fatalf("Do error: %v", err)
+	}
+	if d := c.RxDropped(); d != extra {
+		t.Errorf("RxDropped() = %d, want %d", d, extra)
+	}
+	messagetest.AssertReceive(t, "Endpoint", c.Endpoint.Rx, time.Second, message.New(message.TagBcast, "TIME").AddInt(0))
+}
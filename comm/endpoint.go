@@ -17,6 +17,9 @@ type Endpoint struct {
 
 	// Tx is the channel for transmitting messages from the endpoint.
 	Tx chan<- message.Message
+
+	// Done, if not nil, closes when nothing will read from Tx any more.
+	Done <-chan struct{}
 }
 
 // Recv tries to receive a message on an Endpoint, modulo a context.
@@ -35,14 +38,16 @@ func (e *Endpoint) Recv(ctx context.Context) (*message.Message, error) {
 }
 
 // Send tries to send a message on an Endpoint, modulo a context.
-// It returns false if the given context has been cancelled.
+// It returns false if the given context has been cancelled, or the endpoint's Done channel has closed.
 //
-// Send is just sugar over a Select between Tx and ctx.Done(), and it is
+// Send is just sugar over a Select between Tx, Done, and ctx.Done(), and it is
 // ok to do this manually using the channels themselves.
 func (e *Endpoint) Send(ctx context.Context, r message.Message) bool {
 	select {
 	case <-ctx.Done():
 		return false
+	case <-e.Done:
+		return false
 	case e.Tx <- r:
 	}
 	return true
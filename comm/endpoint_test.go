@@ -3,8 +3,10 @@ package comm
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/UniversityRadioYork/bifrost-go/message"
+	"github.com/UniversityRadioYork/bifrost-go/message/messagetest"
 )
 
 // File bifrost/endpoint_test.go contains tests for the Endpoint struct.
@@ -23,9 +25,7 @@ func testEndpointTxRx(t *testing.T, tx chan<- message.Message, rx <-chan message
 
 	want := message.New("foo", "bar").AddArgs("baz")
 	go func() { tx <- *want }()
-	got := <-rx
-
-	message.AssertMessagesEqual(t, "tx/rx", &got, want)
+	messagetest.AssertReceive(t, "tx/rx", rx, time.Second, want)
 }
 
 func TestEndpoint_SendReceive(t *testing.T) {
@@ -44,7 +44,7 @@ func TestEndpoint_SendReceive(t *testing.T) {
 	if err != nil {
 		t.Fatalf("recv errored: %v", err)
 	}
-	message.AssertMessagesEqual(t, "send/rx", got, want)
+	messagetest.AssertMessagesEqual(t, "send/rx", got, want)
 
 	// After cancelling, sends and receives should fail.
 	cancel()
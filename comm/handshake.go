@@ -0,0 +1,71 @@
+package comm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/UniversityRadioYork/bifrost-go/core"
+	"github.com/UniversityRadioYork/bifrost-go/message"
+)
+
+// File comm/handshake.go contains both sides of the Bifrost handshake.
+
+// Greet performs the server side of the Bifrost handshake with the client on the other end of srvEnd.
+// It sends the client the greeting from core.Greeting, announcing server version serverVer and role role.
+func Greet(ctx context.Context, srvEnd *Endpoint, serverVer, role string) error {
+	for _, m := range core.Greeting(serverVer, role) {
+		if !srvEnd.Send(ctx, *m) {
+			return errors.New("context cancelled during greeting")
+		}
+	}
+	return nil
+}
+
+// handshake performs the client side of the Bifrost handshake with whichever Bifrost service is on the other end
+// of cliEnd; Greet is the server side.
+func handshake(ctx context.Context, cliEnd *Endpoint) (serverVer, role string, err error) {
+	if serverVer, err = recvOhai(ctx, cliEnd); err != nil {
+		return "", "", err
+	}
+	if role, err = recvIama(ctx, cliEnd); err != nil {
+		return "", "", err
+	}
+	return serverVer, role, nil
+}
+
+// recvOhai receives the OHAI part of the handshake on cliEnd.
+func recvOhai(ctx context.Context, cliEnd *Endpoint) (serverVer string, err error) {
+	var (
+		ohaiMsg *message.Message
+		ohai    *core.OhaiResponse
+	)
+	if ohaiMsg, err = cliEnd.Recv(ctx); err != nil {
+		return "", err
+	}
+	if err = core.CheckTag(message.TagBcast, ohaiMsg); err != nil {
+		return "", err
+	}
+	if ohai, err = core.ParseOhaiResponse(ohaiMsg); err != nil {
+		return "", err
+	}
+	// TODO(@MattWindsor91): check protocol version
+	return ohai.ServerVer, nil
+}
+
+// recvIama receives the IAMA part of the handshake on cliEnd.
+func recvIama(ctx context.Context, cliEnd *Endpoint) (role string, err error) {
+	var (
+		iamaMsg *message.Message
+		iama    *core.IamaResponse
+	)
+	if iamaMsg, err = cliEnd.Recv(ctx); err != nil {
+		return "", err
+	}
+	if err = core.CheckTag(message.TagBcast, iamaMsg); err != nil {
+		return "", err
+	}
+	if iama, err = core.ParseIamaResponse(iamaMsg); err != nil {
+		return "", err
+	}
+	return iama.Role, nil
+}
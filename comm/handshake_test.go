@@ -0,0 +1,78 @@
+package comm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/UniversityRadioYork/bifrost-go/core"
+	"github.com/UniversityRadioYork/bifrost-go/message"
+)
+
+// TestHandshake_Greet checks that the client side of the handshake accepts the server side's greeting.
+func TestHandshake_Greet(t *testing.T) {
+	cli, srv := NewEndpointPair()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- Greet(ctx, srv, "test-1.0.0", "player/file") }()
+
+	serverVer, role, err := handshake(ctx, cli)
+	if err != nil {
+		t.Fatalf("handshake error: %v", err)
+	}
+	if serverVer != "test-1.0.0" || role != "player/file" {
+		t.Errorf("handshake got %q, %q; want test-1.0.0, player/file", serverVer, role)
+	}
+	if err := <-errCh; err != nil {
+		t.Errorf("Greet error: %v", err)
+	}
+}
+
+// TestHandshake_badGreeting checks that the client side of the handshake rejects malformed greetings.
+func TestHandshake_badGreeting(t *testing.T) {
+	ohai := core.NewOhaiResponse("test-1.0.0")
+	iama := core.IamaResponse{Role: "player/file"}
+
+	cases := []struct {
+		name     string
+		greeting []*message.Message
+	}{
+		{"wrong order", []*message.Message{iama.Message(message.TagBcast), ohai.Message(message.TagBcast)}},
+		{"OHAI not broadcast", []*message.Message{ohai.Message("f00f"), iama.Message(message.TagBcast)}},
+		{"IAMA not broadcast", []*message.Message{ohai.Message(message.TagBcast), iama.Message("f00f")}},
+	}
+
+	for _, c := range cases {
+		cli, srv := NewEndpointPair()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+		go func(greeting []*message.Message) {
+			for _, m := range greeting {
+				if !srv.Send(ctx, *m) {
+					return
+				}
+			}
+		}(c.greeting)
+
+		if _, _, err := handshake(ctx, cli); err == nil {
+			t.Errorf("%s: handshake succeeded unexpectedly", c.name)
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: handshake timed out", c.name)
+		}
+		cancel()
+	}
+}
+
+// TestGreet_cancelled checks that Greet fails if nobody is listening and the context is cancelled.
+func TestGreet_cancelled(t *testing.T) {
+	_, srv := NewEndpointPair()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := Greet(ctx, srv, "test-1.0.0", "player/file"); err == nil {
+		t.Error("Greet succeeded unexpectedly")
+	}
+}
@@ -19,6 +19,21 @@ type IoEndpoint struct {
 
 	// Bifrost holds the Bifrost channel pair used by the Io.
 	Endpoint *Endpoint
+
+	// Mode controls how incoming bytes are pre-processed before tokenising.
+	// Use message.ModeInteractive for connections from telnet or netcat.
+	Mode message.InputMode
+
+	// TextPolicy is the policy applied to the text of incoming messages.
+	// Servers that pass client text on to other clients should consider rejecting or replacing invalid UTF-8.
+	TextPolicy message.TextPolicy
+
+	// Limits holds the resource limits applied to incoming lines.
+	// Servers facing untrusted clients should set these; a line that breaks them is reported and skipped.
+	Limits message.Limits
+
+	// Recovery is the policy for recovering from damaged incoming lines, such as ones with unclosed quotes.
+	Recovery message.Recovery
 }
 
 func (e *IoEndpoint) Close() error {
@@ -52,19 +67,47 @@ func (e *IoEndpoint) Run(ctx context.Context, errCh chan<- error) {
 
 // runRx runs the client's message receiver loop.
 // This writes messages to the socket.
+//
+// Messages are buffered, and the buffer is flushed whenever there are no more messages immediately waiting on Rx;
+// this means bursts of messages go out in as few writes as possible.
 func (e *IoEndpoint) runRx(ctx context.Context, errCh chan<- error) {
-	// We don't have to check e.Bifrost.Done here:
-	// client always drops both Rx and Done when shutting down.
-	for m := range e.Endpoint.Rx {
-		mbytes, err := m.Pack()
-		if err != nil {
-			e.sendError(ctx, errCh, err)
-			continue
+	w := message.NewWriter(e.Io)
+
+	// The loop ends when Rx closes, or when ctx is done; the latter lets servers shut down connections without
+	// needing whoever sends on Rx to close it.
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-e.Endpoint.Rx:
+			if !ok {
+				return
+			}
+			if err := e.rxBurst(ctx, errCh, w, m); err != nil {
+				e.sendError(ctx, errCh, err)
+				return
+			}
 		}
+	}
+}
 
-		if _, err := e.Io.Write(mbytes); err != nil {
+// rxBurst writes m, and any other messages immediately available on Rx, to w; then flushes w.
+// It reports errors writing individual messages to errCh, and returns any error flushing w.
+func (e *IoEndpoint) rxBurst(ctx context.Context, errCh chan<- error, w *message.Writer, m message.Message) error {
+	for {
+		// I/O errors are sticky, so, if this is one, the flush will catch it and stop the loop.
+		if err := w.WriteMessage(&m); err != nil {
 			e.sendError(ctx, errCh, err)
-			break
+		}
+
+		var ok bool
+		select {
+		case m, ok = <-e.Endpoint.Rx:
+			if !ok {
+				return w.Flush()
+			}
+		default:
+			return w.Flush()
 		}
 	}
 }
@@ -72,10 +115,22 @@ func (e *IoEndpoint) runRx(ctx context.Context, errCh chan<- error) {
 // runTx runs the client's message transmitter loop.
 func (e *IoEndpoint) runTx(ctx context.Context, errCh chan<- error) {
 	r := message.NewReader(e.Io)
+	r.SetMode(e.Mode)
+	r.SetTextPolicy(e.TextPolicy)
+	r.SetLimits(e.Limits)
+	r.SetRecovery(e.Recovery)
 
 	for {
-		if err := e.txLine(ctx, r); err != nil {
-			e.sendError(ctx, errCh, err)
+		err := e.txLine(ctx, r)
+		if err == nil {
+			continue
+		}
+		e.sendError(ctx, errCh, err)
+
+		// Syntax errors only affect the offending line, so we can carry on reading,
+		// unless the input was truncated.
+		var serr message.SyntaxError
+		if !errors.As(err, &serr) || errors.Is(err, io.ErrUnexpectedEOF) {
 			return
 		}
 	}
@@ -83,13 +138,8 @@ func (e *IoEndpoint) runTx(ctx context.Context, errCh chan<- error) {
 
 // txLine transmits a line from the Reader r
 func (e *IoEndpoint) txLine(ctx context.Context, r *message.Reader) (err error) {
-	var line []string
-	if line, err = r.ReadLine(); err != nil {
-		return err
-	}
-
 	var msg *message.Message
-	if msg, err = message.NewFromLine(line); err != nil {
+	if msg, err = r.ReadMessage(); err != nil {
 		return err
 	}
 
@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/UniversityRadioYork/bifrost-go/message"
+	"github.com/UniversityRadioYork/bifrost-go/message/messagetest"
 
 	"github.com/jordwest/mock-conn"
 )
@@ -36,7 +37,7 @@ func TestIoClient_Run_Tx(t *testing.T) {
 		}
 
 		got := <-endp.Rx
-		message.AssertMessagesEqual(t, "tx/rx", &got, c.want)
+		messagetest.AssertMessagesEqual(t, "tx/rx", &got, c.want)
 	}
 
 	if err := tcp.Close(); err != nil {
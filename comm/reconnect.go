@@ -0,0 +1,335 @@
+package comm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/UniversityRadioYork/bifrost-go/message"
+)
+
+// File comm/reconnect.go contains Clients that reconnect to their server when the connection drops.
+
+// ConnState is the enumeration of connection states reported by reconnecting Clients.
+type ConnState int
+
+const (
+	// StateConnected means the client has connected, and completed the handshake.
+	StateConnected ConnState = iota
+	// StateReconnecting means the client has lost its connection, and is about to try to redial.
+	StateReconnecting
+	// StateFailed means the client has given up redialling, and has stopped.
+	StateFailed
+)
+
+// String gets a human-readable name for s.
+func (s ConnState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// StateEvent reports a change in a reconnecting Client's connection state.
+type StateEvent struct {
+	// State is the new state.
+	State ConnState
+
+	// Attempt is the number of the redial attempt since the connection was lost, starting at 1.
+	// It is 0 for the first connection.
+	Attempt int
+
+	// Err, for StateReconnecting and StateFailed, is the reason the connection or last redial attempt failed.
+	Err error
+
+	// ServerVer and Role, for StateConnected, are those the server announced in its greeting.
+	ServerVer, Role string
+}
+
+// Backoff controls the delays between redial attempts.
+//
+// The delay before attempt n is Initial, multiplied by Multiplier n-1 times, and capped at Max.
+// Jitter then shortens the delay by a random fraction of at most Jitter, so that clients that lost their connection
+// at the same time don't all redial at the same time.
+type Backoff struct {
+	// Initial is the delay before the first attempt.
+	Initial time.Duration
+	// Max, if positive, is the longest delay before any attempt.
+	Max time.Duration
+	// Multiplier is the factor by which the delay grows after each attempt; values below 1 count as 1.
+	Multiplier float64
+	// Jitter is the largest fraction of the delay removed at random, between 0 and 1.
+	Jitter float64
+}
+
+// DefaultBackoff is the Backoff used by reconnecting Clients with a zero Backoff.
+var DefaultBackoff = Backoff{
+	Initial:    500 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Delay gets the delay before redial attempt attempt, starting at 1.
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := float64(b.Initial) * math.Pow(math.Max(b.Multiplier, 1), float64(attempt-1))
+	if 0 < b.Max && float64(b.Max) < d {
+		d = float64(b.Max)
+	}
+	d -= d * math.Min(math.Max(b.Jitter, 0), 1) * rand.Float64()
+	return time.Duration(d)
+}
+
+// Reconnect configures a reconnecting Client.
+type Reconnect struct {
+	// Backoff controls the delays between redial attempts; if zero, DefaultBackoff is used.
+	Backoff Backoff
+
+	// MaxAttempts, if positive, is the number of redial attempts after which the client gives up and stops.
+	MaxAttempts int
+
+	// OnState, if not nil, is called with each change in the client's connection state.
+	// It is called on the client's own goroutine, so it shouldn't block for long.
+	OnState func(e StateEvent)
+
+	// Dial, if not nil, replaces the TCP dialler used to connect to the server.
+	Dial func(ctx context.Context, address string) (io.ReadWriteCloser, error)
+}
+
+// DialReconnecting connects to a Bifrost server at address, as in Dial, but redials the server if the connection
+// drops, redoing the handshake each time.
+//
+// The returned Client keeps working across reconnections: its Endpoint, Subscriptions, and Do carry on,
+// though Do calls in progress when the connection drops fail with ErrDisconnected.
+// Messages sent while the client is redialling wait for the next connection, or fail if the client stops.
+// Its ServerVer, Role, and ServerIo are those of the first connection; StateEvents report those of later ones.
+// The client stops when ctx is done, or when it gives up redialling.
+//
+// DialReconnecting doesn't retry the first connection; it fails if that connection fails.
+func DialReconnecting(ctx context.Context, address string, rc Reconnect) (*Client, error) {
+	r := &reconnector{Reconnect: rc, address: address}
+	s, err := r.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.emit(StateEvent{State: StateConnected, ServerVer: s.serverVer, Role: s.role})
+
+	rx := make(chan message.Message, ClientRxBuffer)
+	tx := make(chan message.Message)
+	done := make(chan struct{})
+	c := &Client{
+		ServerVer: s.serverVer,
+		Role:      s.role,
+		Endpoint:  Endpoint{Rx: rx, Tx: tx, Done: done},
+		ServerIo:  s.ioEnd,
+		done:      done,
+		calls:     make(map[string]*call),
+	}
+	go r.run(ctx, c, s, tx, rx)
+	return c, nil
+}
+
+// reconnector holds the state of a reconnecting Client's connection loop.
+type reconnector struct {
+	Reconnect
+
+	// address is the address of the server.
+	address string
+}
+
+// session is a single connection made by a reconnector.
+type session struct {
+	ioEnd  IoEndpoint
+	cliEnd *Endpoint
+	cancel context.CancelFunc
+
+	serverVer, role string
+
+	// done closes once the connection has closed.
+	done chan struct{}
+	// err is the reason the connection closed; it is set before done closes.
+	err error
+}
+
+// run serves the client over s, and each connection redialled after s drops, until ctx is done or redialling fails.
+// It then stops the client.
+func (r *reconnector) run(ctx context.Context, c *Client, s *session, tx <-chan message.Message, out chan<- message.Message) {
+	defer c.stop(out)
+
+	var held *message.Message
+	for {
+		held = r.serve(ctx, c, s, tx, out, held)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A request made through Do fails with the connection, so resending it would surprise its caller;
+		// any other message waits for the next connection.
+		if held != nil && c.callFor(held.Tag()) != nil {
+			held = nil
+		}
+		c.mu.Lock()
+		c.failCalls(ErrDisconnected)
+		c.mu.Unlock()
+
+		var err error
+		if s, err = r.redial(ctx, s.err); err != nil {
+			if ctx.Err() == nil {
+				r.emit(StateEvent{State: StateFailed, Err: err})
+			}
+			return
+		}
+	}
+}
+
+// serve routes messages between the client and s until s closes or ctx is done.
+// It sends held, if not nil, before anything from tx, and returns any message it took from tx but couldn't send.
+func (r *reconnector) serve(ctx context.Context, c *Client, s *session, tx <-chan message.Message, out chan<- message.Message, held *message.Message) *message.Message {
+	// Forwarding requests happens separately from routing responses, so that a full outgoing connection can't stop
+	// the responses that would empty it.
+	fwdDone := make(chan *message.Message)
+	go func() {
+		fwdDone <- s.forward(tx, held)
+	}()
+
+	c.route(ctx, s.cliEnd.Rx, out)
+	s.cancel()
+	held = <-fwdDone
+	<-s.done
+	return held
+}
+
+// forward sends held, if not nil, then messages from tx, to the server until s closes.
+// It returns the message it was sending when s closed, if any, so that it isn't lost.
+func (s *session) forward(tx <-chan message.Message, held *message.Message) *message.Message {
+	for {
+		if held == nil {
+			select {
+			case <-s.done:
+				return nil
+			case m := <-tx:
+				held = &m
+			}
+		}
+		select {
+		case s.cliEnd.Tx <- *held:
+			held = nil
+		case <-s.done:
+			return held
+		}
+	}
+}
+
+// redial tries to reconnect to the server, after a connection that dropped because of cause.
+func (r *reconnector) redial(ctx context.Context, cause error) (*session, error) {
+	b := r.Backoff
+	if b == (Backoff{}) {
+		b = DefaultBackoff
+	}
+
+	err := cause
+	for attempt := 1; r.MaxAttempts <= 0 || attempt <= r.MaxAttempts; attempt++ {
+		r.emit(StateEvent{State: StateReconnecting, Attempt: attempt, Err: err})
+
+		t := time.NewTimer(b.Delay(attempt))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+
+		var s *session
+		if s, err = r.connect(ctx); err == nil {
+			r.emit(StateEvent{State: StateConnected, Attempt: attempt, ServerVer: s.serverVer, Role: s.role})
+			return s, nil
+		}
+	}
+	return nil, err
+}
+
+// connect dials the server and does the handshake.
+func (r *reconnector) connect(ctx context.Context) (*session, error) {
+	conn, err := r.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cliEnd, srvEnd := NewEndpointPair()
+	s := &session{ioEnd: IoEndpoint{Endpoint: srvEnd, Io: conn}, cliEnd: cliEnd, done: make(chan struct{})}
+
+	var sctx context.Context
+	sctx, s.cancel = context.WithCancel(ctx)
+
+	// Closing the connection unblocks the IoEndpoint's reader when we hang up.
+	go func() {
+		<-sctx.Done()
+		_ = conn.Close()
+	}()
+
+	errCh := make(chan error)
+	go s.ioEnd.Run(sctx, errCh)
+	go func() {
+		s.err = s.handleErrors(errCh)
+		// The IoEndpoint has stopped, so we can close cliEnd's Rx channel.
+		_ = s.ioEnd.Close()
+		close(s.done)
+	}()
+
+	if s.serverVer, s.role, err = handshake(sctx, cliEnd); err != nil {
+		s.cancel()
+		<-s.done
+		return nil, err
+	}
+	return s, nil
+}
+
+// handleErrors drains errCh until it closes, hanging up if the server does.
+// It returns the error that best explains why the connection closed.
+func (s *session) handleErrors(errCh <-chan error) error {
+	var cause error
+	for err := range errCh {
+		if errors.Is(err, HungUpError) {
+			s.cancel()
+			if cause == nil {
+				cause = err
+			}
+			continue
+		}
+		// Syntax errors don't close the connection, so they aren't causes.
+		var serr message.SyntaxError
+		if cause == nil && !errors.As(err, &serr) {
+			cause = err
+		}
+	}
+	if cause == nil {
+		cause = HungUpError
+	}
+	return cause
+}
+
+// dial connects to the server using the configured dialler.
+func (r *reconnector) dial(ctx context.Context) (io.ReadWriteCloser, error) {
+	if r.Dial != nil {
+		return r.Dial(ctx, r.address)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", r.address)
+}
+
+// emit reports e to OnState, if set.
+func (r *reconnector) emit(e StateEvent) {
+	if r.OnState != nil {
+		r.OnState(e)
+	}
+}
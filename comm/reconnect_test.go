@@ -0,0 +1,230 @@
+package comm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/UniversityRadioYork/bifrost-go/core"
+	"github.com/UniversityRadioYork/bifrost-go/message"
+	"github.com/UniversityRadioYork/bifrost-go/message/messagetest"
+)
+
+// TestBackoff_Delay checks the delays of a Backoff without jitter.
+func TestBackoff_Delay(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 3}
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 300 * time.Millisecond},
+		{3, 900 * time.Millisecond},
+		{4, time.Second},
+		{40, time.Second},
+	}
+
+	for _, c := range cases {
+		if got := b.Delay(c.attempt); got != c.want {
+			t.Errorf("Delay(%d) = %v, want %v", c.attempt, got, c.want)
+		}
+	}
+}
+
+// TestBackoff_Delay_jitter checks that jitter only ever shortens delays, by at most its fraction.
+func TestBackoff_Delay_jitter(t *testing.T) {
+	b := Backoff{Initial: time.Second, Multiplier: 2, Jitter: 0.25}
+	for i := 0; i < 100; i++ {
+		if got := b.Delay(2); got < 1500*time.Millisecond || 2*time.Second < got {
+			t.Fatalf("Delay(2) = %v, want between 1.5s and 2s", got)
+		}
+	}
+}
+
+// reconnectTestServer starts a Server that acknowledges every request and passes each connection to conns.
+func reconnectTestServer(t *testing.T, conns chan<- *ServerConn) (string, func() error) {
+	t.Helper()
+
+	s := &Server{
+		ServerVer: "test-1.0.0",
+		Role:      "player/file",
+		OnConnect: func(c *ServerConn) {
+			conns <- c
+			for m := range c.Endpoint.Rx {
+				c.Endpoint.Send(c.Context(), *core.AckOk.Message(m.Tag()))
+			}
+		},
+	}
+	return startTestServer(t, s)
+}
+
+// awaitState waits for the next StateEvent on events, and checks its state and attempt.
+func awaitState(t *testing.T, events <-chan StateEvent, state ConnState, attempt int) StateEvent {
+	t.Helper()
+
+	select {
+	case e := <-events:
+		if e.State != state || e.Attempt != attempt {
+			t.Fatalf("got state %v (attempt %d, err %v), want %v (attempt %d)", e.State, e.Attempt, e.Err, state, attempt)
+		}
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for state %v", state)
+		return StateEvent{}
+	}
+}
+
+// TestDialReconnecting checks that a reconnecting Client survives the server hanging up, keeping its Subscriptions.
+func TestDialReconnecting(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conns := make(chan *ServerConn, 2)
+	addr, stop := reconnectTestServer(t, conns)
+	defer func() { _ = stop() }()
+
+	events := make(chan StateEvent, 10)
+	c, err := DialReconnecting(ctx, addr, Reconnect{
+		Backoff: Backoff{Initial: 10 * time.Millisecond},
+		OnState: func(e StateEvent) { events <- e },
+	})
+	if err != nil {
+		t.Fatalf("DialReconnecting error: %v", err)
+	}
+	if e := awaitState(t, events, StateConnected, 0); e.ServerVer != "test-1.0.0" || e.Role != "player/file" {
+		t.Errorf("connected to %q, %q; want test-1.0.0, player/file", e.ServerVer, e.Role)
+	}
+	sub := c.SubscribeWord("STATE", 0)
+
+	if _, err := c.Do(ctx, Request{Word: "PLAY"}); err != nil {
+		t.Fatalf("Do error before reconnect: %v", err)
+	}
+
+	(<-conns).Close()
+	awaitState(t, events, StateReconnecting, 1)
+	awaitState(t, events, StateConnected, 1)
+	sc := <-conns
+
+	if _, err := c.Do(ctx, Request{Word: "STOP"}); err != nil {
+		t.Fatalf("Do error after reconnect: %v", err)
+	}
+	state := message.New(message.TagBcast, "STATE").AddArgs("stopped")
+	if !sc.Endpoint.Send(sc.Context(), *state) {
+		t.Fatal("couldn't send broadcast")
+	}
+	messagetest.AssertReceive(t, "subscription", sub.C, time.Second, state)
+
+	cancel()
+	if _, ok := <-sub.C; ok {
+		t.Error("subscription still open after client stopped")
+	}
+	select {
+	case e := <-events:
+		t.Errorf("unexpected state %v after client stopped", e.State)
+	default:
+	}
+}
+
+// TestDialReconnecting_failed checks that a reconnecting Client gives up after MaxAttempts failed redials.
+func TestDialReconnecting_failed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conns := make(chan *ServerConn, 1)
+	addr, stop := reconnectTestServer(t, conns)
+
+	events := make(chan StateEvent, 10)
+	c, err := DialReconnecting(ctx, addr, Reconnect{
+		Backoff:     Backoff{Initial: 10 * time.Millisecond},
+		MaxAttempts: 2,
+		OnState:     func(e StateEvent) { events <- e },
+	})
+	if err != nil {
+		t.Fatalf("DialReconnecting error: %v", err)
+	}
+	awaitState(t, events, StateConnected, 0)
+	<-conns
+
+	// Shutting the server down hangs up the client, and stops it accepting redials.
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("server error: %v", err)
+	}
+	awaitState(t, events, StateReconnecting, 1)
+	awaitState(t, events, StateReconnecting, 2)
+	if e := awaitState(t, events, StateFailed, 0); e.Err == nil {
+		t.Error("failed state has no error")
+	}
+
+	if _, ok := <-c.Endpoint.Rx; ok {
+		t.Error("client Endpoint still open after failing")
+	}
+	if _, err := c.Do(ctx, Request{Word: "PLAY"}); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Do error %v, want %v", err, ErrClientClosed)
+	}
+}
+
+// TestDialReconnecting_dialError checks that DialReconnecting fails if the first connection fails.
+func TestDialReconnecting_dialError(t *testing.T) {
+	dialErr := errors.New("no route to studio")
+	_, err := DialReconnecting(context.Background(), "studio:1350", Reconnect{
+		Dial: func(context.Context, string) (io.ReadWriteCloser, error) { return nil, dialErr },
+	})
+	if !errors.Is(err, dialErr) {
+		t.Errorf("DialReconnecting error %v, want %v", err, dialErr)
+	}
+}
+
+// TestDialReconnecting_failedWhileSending checks that a request waiting for a redial fails when redialling does.
+func TestDialReconnecting_failedWhileSending(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conns := make(chan *ServerConn, 1)
+	addr, stop := reconnectTestServer(t, conns)
+	defer func() { _ = stop() }()
+
+	dials := 0
+	events := make(chan StateEvent, 10)
+	c, err := DialReconnecting(ctx, addr, Reconnect{
+		Backoff:     Backoff{Initial: 100 * time.Millisecond},
+		MaxAttempts: 1,
+		OnState:     func(e StateEvent) { events <- e },
+		Dial: func(ctx context.Context, address string) (io.ReadWriteCloser, error) {
+			if dials++; dials == 1 {
+				var d net.Dialer
+				return d.DialContext(ctx, "tcp", address)
+			}
+			return nil, errors.New("studio offline")
+		},
+	})
+	if err != nil {
+		t.Fatalf("DialReconnecting error: %v", err)
+	}
+	awaitState(t, events, StateConnected, 0)
+
+	(<-conns).Close()
+	awaitState(t, events, StateReconnecting, 1)
+
+	// Nothing can take this request until the redial, which fails.
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := c.Do(context.Background(), Request{Word: "PLAY"})
+		errCh <- err
+	}()
+	awaitState(t, events, StateFailed, 0)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, ErrClientClosed) {
+			t.Errorf("Do error %v, want %v", err, ErrClientClosed)
+		}
+	case <-ctx.Done():
+		t.Fatal("Do still sending after client failed")
+	}
+	if c.Endpoint.Send(context.Background(), *message.New("f00f", "STOP")) {
+		t.Error("Endpoint.Send succeeded after client failed")
+	}
+}
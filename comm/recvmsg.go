@@ -33,12 +33,7 @@ func ParseMessage(m *message.Message) (Messager, error) {
 
 // ReadMessage reads a line from tokeniser r, then converts it to a Message.
 func ReadMessage(r *message.Reader) (*message.Message, error) {
-	line, err := r.ReadLine()
-	if err != nil {
-		return nil, err
-	}
-
-	return message.NewFromLine(line)
+	return r.ReadMessage()
 }
 
 // ReadAndParse reads a line from tokeniser r, converts it to a Message, then parses it.
@@ -0,0 +1,222 @@
+package comm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/UniversityRadioYork/bifrost-go/message"
+)
+
+// Server accepts connections from Bifrost clients, greets them, and hands them to the application.
+//
+// Each connection gets its own IoEndpoint, and the application talks to the client through the connection's
+// ServerConn.
+type Server struct {
+	// ServerVer is the server version announced to each client in OHAI.
+	ServerVer string
+
+	// Role is the role announced to each client in IAMA.
+	Role string
+
+	// Mode controls how bytes from clients are pre-processed before tokenising.
+	// Servers that expect debugging sessions over telnet or netcat should use message.ModeInteractive.
+	Mode message.InputMode
+
+	// TextPolicy is the policy applied to the text of messages from clients.
+	TextPolicy message.TextPolicy
+
+	// Limits holds the resource limits applied to lines from clients.
+	// The zero Limits enforces nothing, so servers facing untrusted clients should set them.
+	Limits message.Limits
+
+	// Recovery is the policy for recovering from damaged lines from clients,
+	// so that, for example, a stray quote typed into a debug console doesn't swallow the rest of the session.
+	Recovery message.Recovery
+
+	// OnConnect, if not nil, is called with each new connection once the client has been greeted.
+	// It is called on the connection's own goroutine, so it can block to serve the connection until it closes.
+	OnConnect func(c *ServerConn)
+
+	// OnDisconnect, if not nil, is called when a connection that was passed to OnConnect closes,
+	// once OnConnect has returned.
+	OnDisconnect func(c *ServerConn)
+
+	// OnError, if not nil, is called with each error on a connection, such as a malformed line from the client.
+	// The client hanging up is not an error.
+	OnError func(c *ServerConn, err error)
+
+	// lastID is the most recently assigned connection ID.
+	lastID uint64
+}
+
+// ServerConn is the server's side of a single client connection.
+type ServerConn struct {
+	// ID identifies the connection uniquely within its Server.
+	ID string
+
+	// RemoteAddr is the address of the client.
+	RemoteAddr net.Addr
+
+	// Endpoint is the endpoint through which the application talks to the client.
+	// Its Rx channel closes when the connection closes.
+	// Use Endpoint.Send with the connection's Context, so that sends don't block after the connection closes.
+	Endpoint *Endpoint
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Context gets a context that is done when the connection closes.
+func (c *ServerConn) Context() context.Context {
+	return c.ctx
+}
+
+// Close closes the connection.
+func (c *ServerConn) Close() {
+	c.cancel()
+}
+
+// ListenAndServe listens for TCP connections on address, then serves them as in Serve.
+func (s *Server) ListenAndServe(ctx context.Context, address string) error {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, l)
+}
+
+// Maximum and initial delays between retries of temporary Accept errors, such as running out of file descriptors.
+const (
+	maxAcceptDelay  = time.Second
+	initAcceptDelay = 5 * time.Millisecond
+)
+
+// Serve accepts connections on l until ctx is done or l fails, serving each on its own goroutine.
+// Temporary errors from l are retried after a short delay, without disturbing existing connections.
+// It closes l, and waits for every connection to close, before returning.
+// If ctx is done, Serve returns ctx.Err(); otherwise, it returns the error from l.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	sctx, cancel := context.WithCancel(ctx)
+	go func() {
+		<-sctx.Done()
+		_ = l.Close()
+	}()
+
+	var (
+		wg    sync.WaitGroup
+		delay time.Duration
+	)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() == nil && isTemporary(err) {
+				delay = nextAcceptDelay(delay)
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+				}
+				continue
+			}
+
+			if cerr := ctx.Err(); cerr != nil {
+				err = cerr
+			}
+			cancel()
+			wg.Wait()
+			return err
+		}
+		delay = 0
+
+		wg.Add(1)
+		go func() {
+			s.serveConn(sctx, conn)
+			wg.Done()
+		}()
+	}
+}
+
+// nextAcceptDelay gets the delay before retrying Accept, given the last delay.
+func nextAcceptDelay(last time.Duration) time.Duration {
+	if last == 0 {
+		return initAcceptDelay
+	}
+	if last *= 2; maxAcceptDelay < last {
+		return maxAcceptDelay
+	}
+	return last
+}
+
+// isTemporary checks whether err is a temporary network error, which Accept can recover from.
+func isTemporary(err error) bool {
+	var nerr net.Error
+	return errors.As(err, &nerr) && nerr.Temporary()
+}
+
+// serveConn serves a single connection until it closes.
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	appEnd, ioSide := NewEndpointPair()
+	ioEnd := IoEndpoint{Io: conn, Endpoint: ioSide, Mode: s.Mode, TextPolicy: s.TextPolicy, Limits: s.Limits, Recovery: s.Recovery}
+
+	c := &ServerConn{
+		ID:         strconv.FormatUint(atomic.AddUint64(&s.lastID, 1), 10),
+		RemoteAddr: conn.RemoteAddr(),
+		Endpoint:   appEnd,
+	}
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	defer c.cancel()
+
+	// Closing the connection unblocks the IoEndpoint's reader when we shut down.
+	go func() {
+		<-c.ctx.Done()
+		_ = conn.Close()
+	}()
+
+	errCh := make(chan error)
+	go ioEnd.Run(c.ctx, errCh)
+
+	stopped := make(chan struct{})
+	go func() {
+		s.handleErrors(c, errCh)
+		// The IoEndpoint has stopped, so we can close the application's Rx channel.
+		_ = ioEnd.Close()
+		close(stopped)
+	}()
+
+	connected := s.greet(c)
+	if connected && s.OnConnect != nil {
+		s.OnConnect(c)
+	}
+
+	<-stopped
+	if connected && s.OnDisconnect != nil {
+		s.OnDisconnect(c)
+	}
+}
+
+// handleErrors passes errors from errCh to OnError until errCh closes.
+// The client hanging up isn't an error, but closes c.
+func (s *Server) handleErrors(c *ServerConn, errCh <-chan error) {
+	for err := range errCh {
+		if errors.Is(err, HungUpError) {
+			c.Close()
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			continue
+		}
+		if s.OnError != nil {
+			s.OnError(c, err)
+		}
+	}
+}
+
+// greet sends the greeting to the client on c, returning false if c closes first.
+func (s *Server) greet(c *ServerConn) bool {
+	return Greet(c.ctx, c.Endpoint, s.ServerVer, s.Role) == nil
+}
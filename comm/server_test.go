@@ -0,0 +1,345 @@
+package comm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/UniversityRadioYork/bifrost-go/core"
+	"github.com/UniversityRadioYork/bifrost-go/message"
+	"github.com/UniversityRadioYork/bifrost-go/message/messagetest"
+)
+
+// startTestServer starts s on a local TCP port, returning the port's address and a function that shuts s down
+// and returns the result of Serve.
+func startTestServer(t *testing.T, s *Server) (string, func() error) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- s.Serve(ctx, l) }()
+
+	return l.Addr().String(), func() error {
+		cancel()
+		select {
+		case err := <-result:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("server didn't shut down")
+			return nil
+		}
+	}
+}
+
+// TestServer_echo tests a Server that echoes requests back to clients, checking the greeting, the echo,
+// and the connect and disconnect callbacks.
+func TestServer_echo(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(e string) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}
+	disconnected := make(chan struct{})
+
+	s := &Server{
+		ServerVer: "test-1.0.0",
+		Role:      "echo",
+		OnConnect: func(c *ServerConn) {
+			record("connect " + c.ID)
+			for m := range c.Endpoint.Rx {
+				c.Endpoint.Send(c.Context(), m)
+			}
+		},
+		OnDisconnect: func(c *ServerConn) {
+			record("disconnect " + c.ID)
+			close(disconnected)
+		},
+	}
+	addr, stop := startTestServer(t, s)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	r := message.NewReader(conn)
+
+	want := append(core.Greeting("test-1.0.0", "echo"), message.New("f00f", "read").AddArgs("/player/time"))
+	if _, err := conn.Write([]byte("f00f read /player/time\n")); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	for i, w := range want {
+		got, err := r.ReadMessage()
+		if err != nil {
+			t.Fatalf("read %d error: %v", i, err)
+		}
+		messagetest.AssertMessagesEqual(t, "server echo", got, w)
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+	select {
+	case <-disconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server didn't notice client hanging up")
+	}
+
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Serve returned %v; want context.Canceled", err)
+	}
+	if len(events) != 2 || events[0] != "connect 1" || events[1] != "disconnect 1" {
+		t.Errorf("events = %q; want connect and disconnect of 1", events)
+	}
+}
+
+// TestServer_shutdown tests that shutting down a Server closes its open connections.
+func TestServer_shutdown(t *testing.T) {
+	connected := make(chan *ServerConn, 1)
+	s := &Server{
+		OnConnect: func(c *ServerConn) { connected <- c },
+	}
+	addr, stop := startTestServer(t, s)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer conn.Close()
+
+	var c *ServerConn
+	select {
+	case c = <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server didn't connect")
+	}
+
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Serve returned %v; want context.Canceled", err)
+	}
+
+	select {
+	case <-c.Context().Done():
+	default:
+		t.Error("connection context not done after shutdown")
+	}
+	if _, ok := <-c.Endpoint.Rx; ok {
+		t.Error("connection Rx still open after shutdown")
+	}
+
+	// The client should see the server hang up after the greeting.
+	r := message.NewReader(conn)
+	var rerr error
+	for rerr == nil {
+		_, rerr = r.ReadLine()
+	}
+	if !errors.Is(rerr, io.EOF) {
+		t.Errorf("client read gave %v; want EOF", rerr)
+	}
+}
+
+// TestServer_OnError tests that a Server reports bad lines from clients.
+func TestServer_OnError(t *testing.T) {
+	errs := make(chan error, 1)
+	s := &Server{
+		OnConnect: func(c *ServerConn) {
+			for range c.Endpoint.Rx {
+			}
+		},
+		OnError: func(_ *ServerConn, err error) { errs <- err },
+	}
+	addr, stop := startTestServer(t, s)
+	defer stop()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("lonely\n")); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, message.ErrTooFewWords) {
+			t.Errorf("OnError got %v; want ErrTooFewWords", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no error reported")
+	}
+}
+
+// badLineServer configures s to echo requests and report errors to errs, then checks that, after sending in to it,
+// it reports an error and echoes want.
+func badLineServer(t *testing.T, s *Server, in string, want *message.Message) error {
+	t.Helper()
+
+	errs := make(chan error, 1)
+	s.ServerVer = "test-1.0.0"
+	s.Role = "echo"
+	s.OnConnect = func(c *ServerConn) {
+		for m := range c.Endpoint.Rx {
+			c.Endpoint.Send(c.Context(), m)
+		}
+	}
+	s.OnError = func(_ *ServerConn, err error) { errs <- err }
+	addr, stop := startTestServer(t, s)
+	defer stop()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(in)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+
+	var got error
+	select {
+	case got = <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no error reported")
+	}
+
+	r := message.NewReader(conn)
+	for i, w := range append(core.Greeting("test-1.0.0", "echo"), want) {
+		m, err := r.ReadMessage()
+		if err != nil {
+			t.Fatalf("read %d error: %v", i, err)
+		}
+		messagetest.AssertMessagesEqual(t, "server echo", m, w)
+	}
+	return got
+}
+
+// TestServer_Limits tests that a Server rejects lines breaking its Limits, but carries on serving the client.
+func TestServer_Limits(t *testing.T) {
+	s := &Server{Limits: message.Limits{MaxLineLength: 32}}
+	err := badLineServer(t, s, "f00f load "+strings.Repeat("x", 64)+"\nf00f eject\n", message.New("f00f", "eject"))
+
+	var lerr message.LimitError
+	if !errors.As(err, &lerr) {
+		t.Errorf("OnError got %v; want LimitError", err)
+	}
+}
+
+// TestServer_Recovery tests that a Server recovers from damaged lines under its Recovery policy.
+func TestServer_Recovery(t *testing.T) {
+	s := &Server{Recovery: message.Recovery{LimitQuotedNewlines: true}}
+	err := badLineServer(t, s, "f00f load 'oops\nf00f eject\n", message.New("f00f", "eject"))
+
+	var derr message.DamagedLineError
+	if !errors.As(err, &derr) {
+		t.Errorf("OnError got %v; want DamagedLineError", err)
+	}
+}
+
+// TestServer_Mode tests that a Server pre-processes input from interactive clients under its Mode.
+func TestServer_Mode(t *testing.T) {
+	s := &Server{
+		ServerVer: "test-1.0.0",
+		Role:      "echo",
+		Mode:      message.ModeInteractive,
+		OnConnect: func(c *ServerConn) {
+			for m := range c.Endpoint.Rx {
+				c.Endpoint.Send(c.Context(), m)
+			}
+		},
+	}
+	addr, stop := startTestServer(t, s)
+	defer stop()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer conn.Close()
+	// A telnet client negotiates options (here, IAC WILL LINEMODE) and ends lines with CRLF.
+	if _, err := conn.Write([]byte("\xff\xfb\x22f00f play\r\n")); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+
+	r := message.NewReader(conn)
+	for i, w := range append(core.Greeting("test-1.0.0", "echo"), message.New("f00f", "play")) {
+		m, err := r.ReadMessage()
+		if err != nil {
+			t.Fatalf("read %d error: %v", i, err)
+		}
+		messagetest.AssertMessagesEqual(t, "server echo", m, w)
+	}
+}
+
+// tempError is a temporary network error.
+type tempError struct{}
+
+func (tempError) Error() string   { return "too many open files" }
+func (tempError) Timeout() bool   { return false }
+func (tempError) Temporary() bool { return true }
+
+// flakyListener is a net.Listener whose Accept fails with a temporary error until failures runs out.
+type flakyListener struct {
+	net.Listener
+	failures chan struct{}
+}
+
+func (l flakyListener) Accept() (net.Conn, error) {
+	select {
+	case <-l.failures:
+		return nil, tempError{}
+	default:
+		return l.Listener.Accept()
+	}
+}
+
+// TestServer_temporaryAcceptError tests that a Server keeps serving through temporary Accept errors.
+func TestServer_temporaryAcceptError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+	failures := make(chan struct{}, 3)
+	for i := 0; i < cap(failures); i++ {
+		failures <- struct{}{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s := &Server{ServerVer: "test-1.0.0", Role: "echo"}
+	result := make(chan error, 1)
+	go func() { result <- s.Serve(ctx, flakyListener{Listener: l, failures: failures}) }()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer conn.Close()
+
+	r := message.NewReader(conn)
+	for i, w := range core.Greeting("test-1.0.0", "echo") {
+		m, err := r.ReadMessage()
+		if err != nil {
+			t.Fatalf("read %d error: %v", i, err)
+		}
+		messagetest.AssertMessagesEqual(t, "greeting", m, w)
+	}
+
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("Serve error %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,134 @@
+package comm
+
+import (
+	"sync/atomic"
+
+	"github.com/UniversityRadioYork/bifrost-go/message"
+)
+
+// File comm/subscribe.go contains subscriptions to broadcasts received by Clients.
+
+// DefaultSubscriptionBuffer is the buffer size of a Subscription created with a non-positive buffer size.
+const DefaultSubscriptionBuffer = 16
+
+// Subscription receives copies of the broadcasts a Client receives that match a pattern.
+//
+// Each Subscription has its own buffer.
+// If the buffer is full when a broadcast arrives, the Subscription drops the broadcast rather than stalling the client;
+// Dropped counts how many broadcasts it has dropped.
+type Subscription struct {
+	// C receives each matching broadcast.
+	// It closes when the Subscription is closed, or its client stops.
+	C <-chan message.Message
+
+	// c is the sending end of C.
+	c chan message.Message
+	// pattern is the pattern broadcasts must match; if nil, all broadcasts match.
+	pattern *message.Pattern
+	// client is the client this Subscription belongs to.
+	client *Client
+	// dropped is the number of broadcasts dropped because C's buffer was full.
+	dropped uint64
+}
+
+// Subscribe creates a Subscription to the broadcasts this client receives that match p.
+// If p is nil, the Subscription receives every broadcast.
+// C has buffer size buffer, or DefaultSubscriptionBuffer if buffer isn't positive.
+//
+// Broadcasts matching at least one Subscription don't go to the client's Endpoint.
+// If the client has stopped, C is already closed.
+func (c *Client) Subscribe(p *message.Pattern, buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultSubscriptionBuffer
+	}
+	ch := make(chan message.Message, buffer)
+	s := &Subscription{C: ch, c: ch, pattern: p, client: c}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calls == nil {
+		close(ch)
+		return s
+	}
+	if c.subs == nil {
+		c.subs = make(map[*Subscription]struct{})
+	}
+	c.subs[s] = struct{}{}
+	return s
+}
+
+// SubscribeWord creates a Subscription to the broadcasts this client receives with message word word.
+// See Subscribe.
+func (c *Client) SubscribeWord(word string, buffer int) *Subscription {
+	return c.Subscribe(&message.Pattern{Tag: message.Exact(message.TagBcast), Word: message.Exact(word), Rest: true}, buffer)
+}
+
+// SubscribeFunc is like Subscribe, but calls f on each matching broadcast, in order, from its own goroutine.
+// f stops being called once the Subscription is closed and its buffer is empty.
+func (c *Client) SubscribeFunc(p *message.Pattern, buffer int, f func(*message.Message)) *Subscription {
+	s := c.Subscribe(p, buffer)
+	go func() {
+		for m := range s.C {
+			m := m
+			f(&m)
+		}
+	}()
+	return s
+}
+
+// Dropped gets the number of broadcasts this Subscription has dropped because its buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close stops this Subscription receiving broadcasts, and closes C.
+// Closing a Subscription more than once has no effect.
+func (s *Subscription) Close() {
+	c := s.client
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.subs[s]; ok {
+		delete(c.subs, s)
+		close(s.c)
+	}
+}
+
+// offer sends a copy of broadcast m to this Subscription if it matches, dropping it if the buffer is full.
+// It returns whether m matched.
+// The client's mutex must be held.
+func (s *Subscription) offer(m *message.Message) bool {
+	if s.pattern != nil && !s.pattern.Match(m) {
+		return false
+	}
+	select {
+	case s.c <- *m.Clone():
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+	return true
+}
+
+// publish offers broadcast m to each of the client's Subscriptions.
+// It returns whether m matched any of them.
+func (c *Client) publish(m *message.Message) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	matched := false
+	for s := range c.subs {
+		if s.offer(m) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// closeSubs closes all of the client's Subscriptions.
+// The client's mutex must be held.
+func (c *Client) closeSubs() {
+	for s := range c.subs {
+		close(s.c)
+	}
+	c.subs = nil
+}
@@ -0,0 +1,118 @@
+package comm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/UniversityRadioYork/bifrost-go/message"
+	"github.com/UniversityRadioYork/bifrost-go/message/messagetest"
+)
+
+// TestClient_Subscribe checks that broadcasts go to matching Subscriptions, and everything else to the Endpoint.
+func TestClient_Subscribe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, srv := newTestClient(ctx, t)
+	states := c.SubscribeWord("STATE", 0)
+	times := c.Subscribe(message.MustParsePattern("! TIME ..."), 0)
+	all := c.Subscribe(nil, 0)
+
+	state := message.New(message.TagBcast, "STATE").AddArgs("playing")
+	tm := message.New(message.TagBcast, "TIME").AddArgs("1000")
+	// Not a broadcast, so it goes to the Endpoint even though all matches everything.
+	other := message.New("f00f", "STATE").AddArgs("stopped")
+	go func() {
+		for _, m := range []*message.Message{state, tm, other} {
+			srv.Send(ctx, *m)
+		}
+	}()
+
+	messagetest.AssertReceive(t, "Endpoint", c.Endpoint.Rx, time.Second, other)
+	messagetest.AssertReceive(t, "STATE subscription", states.C, time.Second, state)
+	messagetest.AssertReceive(t, "TIME subscription", times.C, time.Second, tm)
+	messagetest.AssertReceive(t, "catch-all subscription", all.C, time.Second, state, tm)
+	messagetest.AssertNoMessage(t, "STATE subscription", states.C, 10*time.Millisecond)
+}
+
+// TestClient_Subscribe_unmatched checks that broadcasts matching no Subscription go to the Endpoint.
+func TestClient_Subscribe_unmatched(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, srv := newTestClient(ctx, t)
+	s := c.SubscribeWord("STATE", 0)
+
+	tm := message.New(message.TagBcast, "TIME").AddArgs("1000")
+	go srv.Send(ctx, *tm)
+
+	messagetest.AssertReceive(t, "Endpoint", c.Endpoint.Rx, time.Second, tm)
+	messagetest.AssertNoMessage(t, "subscription", s.C, 10*time.Millisecond)
+}
+
+// TestClient_Subscribe_slow checks that a full Subscription drops broadcasts without stalling the client or other
+// Subscriptions.
+func TestClient_Subscribe_slow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, srv := newTestClient(ctx, t)
+	slow := c.SubscribeWord("TIME", 1)
+
+	got := make(chan *message.Message, 10)
+	c.SubscribeFunc(nil, 10, func(m *message.Message) { got <- m })
+
+	const n = 5
+	for i := 0; i < n; i++ {
+		if !srv.Send(ctx, *message.New(message.TagBcast, "TIME").AddArgs("1000")) {
+			t.Fatal("timed out sending broadcast")
+		}
+	}
+	// This only gets through if the dispatcher isn't stuck on the slow subscriber.
+	other := message.New("f00f", "ACK").AddArgs("OK", "success")
+	go srv.Send(ctx, *other)
+	messagetest.AssertReceive(t, "Endpoint", c.Endpoint.Rx, time.Second, other)
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-got:
+		case <-ctx.Done():
+			t.Fatalf("callback got %d broadcasts, want %d", i, n)
+		}
+	}
+	if d := slow.Dropped(); d != n-1 {
+		t.Errorf("slow subscription dropped %d, want %d", d, n-1)
+	}
+}
+
+// TestSubscription_Close checks that closing a Subscription, or stopping its client, closes its channel.
+func TestSubscription_Close(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, srv := newTestClient(ctx, t)
+	s1 := c.Subscribe(nil, 0)
+	s2 := c.Subscribe(nil, 0)
+
+	s1.Close()
+	s1.Close()
+	if _, ok := <-s1.C; ok {
+		t.Error("closed subscription received a message")
+	}
+
+	close(srv.Tx)
+	select {
+	case _, ok := <-s2.C:
+		if ok {
+			t.Error("subscription received a message after client stopped")
+		}
+	case <-ctx.Done():
+		t.Fatal("subscription not closed when client stopped")
+	}
+	s2.Close()
+
+	if _, ok := <-c.Subscribe(nil, 0).C; ok {
+		t.Error("subscription to stopped client received a message")
+	}
+}
@@ -47,6 +47,33 @@ func ErrorAck(err error) *AckResponse {
 	}
 }
 
+// Err converts an AckResponse into a Go error.
+// It returns nil if the status is OK, and an AckError otherwise.
+func (a AckResponse) Err() error {
+	if a.Status == StatusOk {
+		return nil
+	}
+	return AckError(a)
+}
+
+// AckError is the error form of an AckResponse with a status other than OK.
+type AckError AckResponse
+
+// Error implements the error protocol for AckError.
+func (a AckError) Error() string {
+	return a.Status.String() + ": " + a.Description
+}
+
+// Blame implements blaming for AckError.
+// A WHAT means the request was bad, and so blames the client; anything else blames the server.
+// This means that ErrorAck, given an AckError, gives back an ACK with the same status.
+func (a AckError) Blame() Blame {
+	if a.Status == StatusWhat {
+		return BlameClient
+	}
+	return BlameServer
+}
+
 // ParseAckResponse tries to parse an arbitrary message as an ACK response.
 func ParseAckResponse(m *message.Message) (*AckResponse, error) {
 	var err error
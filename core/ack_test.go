@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/UniversityRadioYork/bifrost-go/message"
+	"github.com/UniversityRadioYork/bifrost-go/message/messagetest"
 )
 
 // ExampleErrorAck is a testable example for ErrorAck.
@@ -90,6 +91,11 @@ var errorAckCases = []struct {
 	}, StatusWhat},
 	{failError{}, StatusFail},
 	{errors.New("anonymous"), StatusFail},
+	{message.ArgError{Index: 0, Value: "x", Want: "an integer"}, StatusWhat},
+	{message.LimitError{Limit: message.LimitWordCount, Max: 10}, StatusWhat},
+	{message.SyntaxError{Err: message.ErrTooFewWords}, StatusWhat},
+	{AckError{Status: StatusWhat, Description: "u wot, m8?"}, StatusWhat},
+	{AckError{Status: StatusFail, Description: "computer says no"}, StatusFail},
 }
 
 // TestAckResponse_Message tests applying the Message method to various AckResponses.
@@ -97,7 +103,7 @@ func TestAckResponse_Message(t *testing.T) {
 	for _, c := range ackResponseMessageCases {
 		got := c.input.Message(c.tag)
 		gotStr := fmt.Sprintf("(%q).Message(%s)", c.input, c.tag)
-		message.AssertMessagesEqual(t, gotStr, got, c.want)
+		messagetest.AssertMessagesEqual(t, gotStr, got, c.want)
 	}
 }
 
@@ -117,6 +123,26 @@ func TestParseAckResponse_roundTrip(t *testing.T) {
 	}
 }
 
+// TestAckResponse_Err checks that Err converts non-OK AckResponses into AckErrors, and back again via ErrorAck.
+func TestAckResponse_Err(t *testing.T) {
+	for _, c := range ackResponseRoundTripCases {
+		err := c.Err()
+		if c.Status == StatusOk {
+			if err != nil {
+				t.Errorf("(%v).Err() = %v; want nil", c, err)
+			}
+			continue
+		}
+
+		var aerr AckError
+		if !errors.As(err, &aerr) {
+			t.Errorf("(%v).Err() = %v; want AckError", c, err)
+		} else if back := ErrorAck(err); back.Status != c.Status {
+			t.Errorf("ErrorAck((%v).Err()) has status %s; want %s", c, back.Status, c.Status)
+		}
+	}
+}
+
 // TestParseAckResponse_wordError checks that ParseAckResponse handles word errors correctly
 func TestParseAckResponse_wordError(t *testing.T) {
 	// Hopefully this is a decently representative set of variations on ACK.
@@ -1,6 +1,10 @@
 package core
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/UniversityRadioYork/bifrost-go/message"
+)
 
 // Blame is the enumeration of possible causes for a Bifrost-related error.
 // It is used mainly to work out whether to send a WHAT or a FAIL.
@@ -34,10 +38,30 @@ type Blameable interface {
 }
 
 // ErrorBlame returns err's Blame() if it is Blameable, and BlameUnknown otherwise.
+//
+// The message package can't depend on Blame, so ErrorBlame also blames the client for errors from that package
+// that describe bad input; see isMessageInputError.
 func ErrorBlame(err error) Blame {
 	var errb Blameable
-	if !errors.As(err, &errb) {
-		return BlameUnknown
+	if errors.As(err, &errb) {
+		return errb.Blame()
+	}
+	if isMessageInputError(err) {
+		return BlameClient
 	}
-	return errb.Blame()
+	return BlameUnknown
+}
+
+// isMessageInputError checks whether err is one of the errors the message package raises for bad input.
+func isMessageInputError(err error) bool {
+	var (
+		aerr message.ArgError
+		rerr message.ArityError
+		lerr message.LimitError
+		terr message.TruncatedError
+		serr message.SyntaxError
+		eerr message.EncodingError
+	)
+	return errors.As(err, &aerr) || errors.As(err, &rerr) || errors.As(err, &lerr) || errors.As(err, &terr) ||
+		errors.As(err, &serr) || errors.As(err, &eerr)
 }
@@ -2,7 +2,6 @@ package core
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/UniversityRadioYork/bifrost-go/message"
@@ -38,34 +37,39 @@ func CheckWord(want string, m *message.Message) error {
 	return nil
 }
 
-// ArityError is sent when a parser expects a certain number of arguments, but gets a wrong amount.
-type ArityError struct {
-	// Got is the number of arguments the parser got.
-	Got int
+// TagError is sent when a parser expects a message with one tag, but gets another.
+type TagError struct {
+	// Got is the tag that the parser got.
+	Got string
 
-	// Min is the minimum number of arguments the parser expected.
-	Min int
+	// Want is the tag that the parser expected.
+	Want string
+}
 
-	// Max is the maximum number of arguments the parser expected.
-	Max int
+func (t TagError) Error() string {
+	return fmt.Sprintf("message tag is '%s', want '%s'", t.Got, t.Want)
 }
 
-func (a ArityError) Error() string {
-	return fmt.Sprintf("message has %s, want %s", a.got(), a.want())
+func (t TagError) Blame() Blame {
+	return BlameClient
 }
 
-func (a ArityError) got() string {
-	if a.Got == 1 {
-		return "one argument"
+// CheckTag checks to see if the message m has the tag want.
+// It returns a TagError if not.
+func CheckTag(want string, m *message.Message) error {
+	if got := m.Tag(); got != want {
+		return TagError{Got: got, Want: want}
 	}
-	return fmt.Sprintf("%d arguments", a.Got)
+	return nil
 }
 
-func (a ArityError) want() string {
-	if a.Min != a.Max {
-		return fmt.Sprintf("%d-%d", a.Min, a.Max)
-	}
-	return strconv.Itoa(a.Min)
+// ArityError is sent when a parser expects a certain number of arguments, but gets a wrong amount.
+//
+// It has the same fields as message.ArityError, which message.Unmarshal uses, and converts to and from it.
+type ArityError message.ArityError
+
+func (a ArityError) Error() string {
+	return message.ArityError(a).Error()
 }
 
 func (a ArityError) Blame() Blame {
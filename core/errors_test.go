@@ -133,3 +133,16 @@ func testParserWordError(t *testing.T, err error, want, got string) {
 		t.Errorf("ack ArityError has got=%q; should be %q", w.Got, got)
 	}
 }
+
+// TestArityError_Blame checks that ArityErrors are Blameable, and blame the client.
+func TestArityError_Blame(t *testing.T) {
+	var err error = ArityError{Got: 3, Min: 1, Max: 2}
+
+	var b Blameable
+	if !errors.As(err, &b) {
+		t.Fatal("ArityError isn't Blameable")
+	}
+	if got := b.Blame(); got != BlameClient {
+		t.Errorf("ArityError blame = %v, want %v", got, BlameClient)
+	}
+}
@@ -0,0 +1,15 @@
+package core
+
+import "github.com/UniversityRadioYork/bifrost-go/message"
+
+// File core/greeting.go describes the greeting a Bifrost server sends to each new client.
+
+// Greeting gets the messages a Bifrost server with version serverVer and role role sends to each new client.
+// These are an OHAI announcing the protocol and server versions, then an IAMA announcing the role;
+// both are tagged as broadcasts, as the client didn't ask for them.
+func Greeting(serverVer, role string) []*message.Message {
+	return []*message.Message{
+		NewOhaiResponse(serverVer).Message(message.TagBcast),
+		IamaResponse{Role: role}.Message(message.TagBcast),
+	}
+}
@@ -0,0 +1,28 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/UniversityRadioYork/bifrost-go/message"
+)
+
+// ExampleGreeting is a testable example for Greeting.
+func ExampleGreeting() {
+	for _, m := range Greeting("example-42.0.0", "player/file") {
+		fmt.Print(m)
+	}
+
+	// Output:
+	// ! OHAI bifrost-0.0.0 example-42.0.0
+	// ! IAMA player/file
+}
+
+// ExampleCheckTag is a testable example for CheckTag.
+func ExampleCheckTag() {
+	fmt.Println(CheckTag(message.TagBcast, message.New(message.TagBcast, RsOhai)))
+	fmt.Println(CheckTag(message.TagBcast, message.New("f00f", RsOhai)))
+
+	// Output:
+	// <nil>
+	// message tag is 'f00f', want '!'
+}
@@ -22,6 +22,11 @@ type OhaiResponse struct {
 	ServerVer string
 }
 
+// NewOhaiResponse creates an OhaiResponse announcing this library's protocol version, and server version serverVer.
+func NewOhaiResponse(serverVer string) OhaiResponse {
+	return OhaiResponse{ProtocolVer: ThisProtocolVer, ServerVer: serverVer}
+}
+
 // Message converts an OhaiResponse into an OHAI message with tag tag.
 func (o OhaiResponse) Message(tag string) *message.Message {
 	return message.New(tag, RsOhai).AddArgs(o.ProtocolVer, o.ServerVer)
@@ -5,4 +5,5 @@ go 1.13
 require (
 	github.com/google/uuid v1.1.1
 	github.com/jordwest/mock-conn v0.0.0-20180617021051-4896c6bd1641
+	golang.org/x/text v0.3.8
 )
@@ -0,0 +1,213 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File message/args.go contains typed accessors and builders for Message arguments.
+
+// ArgError is the error returned when a Message argument is missing, or can't be converted to the requested type.
+//
+// ArgErrors are the fault of whoever sent the message; core.ErrorBlame blames them on the client.
+type ArgError struct {
+	// Index is the index of the offending argument.
+	Index int
+
+	// Value is the offending argument, if it exists.
+	Value string
+
+	// Want describes the sort of argument that was wanted.
+	Want string
+
+	// Missing is true if the argument didn't exist.
+	Missing bool
+
+	// Err, if not nil, is the underlying conversion error.
+	Err error
+}
+
+// Error implements the error protocol for ArgError.
+func (a ArgError) Error() string {
+	if a.Missing {
+		return fmt.Sprintf("argument %d is missing, want %s", a.Index, a.Want)
+	}
+	if a.Err != nil {
+		return fmt.Sprintf("argument %d (%q) is not %s: %v", a.Index, a.Value, a.Want, a.Err)
+	}
+	return fmt.Sprintf("argument %d (%q) is not %s", a.Index, a.Value, a.Want)
+}
+
+// Unwrap gets the conversion error underlying an ArgError, if any.
+func (a ArgError) Unwrap() error {
+	return a.Err
+}
+
+// ArityError is sent when a parser expects a certain number of arguments, but gets a wrong amount.
+//
+// ArityErrors are the fault of whoever sent the message; core.ErrorBlame blames them on the client.
+type ArityError struct {
+	// Got is the number of arguments the parser got.
+	Got int
+
+	// Min is the minimum number of arguments the parser expected.
+	Min int
+
+	// Max is the maximum number of arguments the parser expected.
+	// If negative, there is no maximum.
+	Max int
+}
+
+func (a ArityError) Error() string {
+	return fmt.Sprintf("message has %s, want %s", a.got(), a.want())
+}
+
+func (a ArityError) got() string {
+	if a.Got == 1 {
+		return "one argument"
+	}
+	return fmt.Sprintf("%d arguments", a.Got)
+}
+
+func (a ArityError) want() string {
+	switch {
+	case a.Max < 0:
+		return fmt.Sprintf("at least %d", a.Min)
+	case a.Min != a.Max:
+		return fmt.Sprintf("%d-%d", a.Min, a.Max)
+	default:
+		return strconv.Itoa(a.Min)
+	}
+}
+
+// typedArg gets the index-th argument of m, returning an ArgError wanting want if it doesn't exist.
+func (m *Message) typedArg(index int, want string) (string, error) {
+	if index < 0 || len(m.args) <= index {
+		return "", ArgError{Index: index, Want: want, Missing: true}
+	}
+	return m.args[index], nil
+}
+
+// numError strips the function and input information from strconv errors, as ArgError already has them.
+func numError(err error) error {
+	var nerr *strconv.NumError
+	if errors.As(err, &nerr) {
+		return nerr.Err
+	}
+	return err
+}
+
+// ArgInt gets the index-th argument of m as a decimal integer.
+func (m *Message) ArgInt(index int) (int, error) {
+	const want = "an integer"
+
+	s, err := m.typedArg(index, want)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, ArgError{Index: index, Value: s, Want: want, Err: numError(err)}
+	}
+	return i, nil
+}
+
+// ArgBool gets the index-th argument of m as a Boolean.
+// It accepts 'true', 'on', 'yes', and '1' as true; and 'false', 'off', 'no', and '0' as false.
+func (m *Message) ArgBool(index int) (bool, error) {
+	const want = "a Boolean"
+
+	s, err := m.typedArg(index, want)
+	if err != nil {
+		return false, err
+	}
+	b, ok := parseBool(s)
+	if !ok {
+		return false, ArgError{Index: index, Value: s, Want: want}
+	}
+	return b, nil
+}
+
+// parseBool parses s as a Boolean argument, returning false as its second value if s isn't one.
+func parseBool(s string) (value, ok bool) {
+	switch s {
+	case "true", "on", "yes", "1":
+		return true, true
+	case "false", "off", "no", "0":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// ArgDuration gets the index-th argument of m as a duration.
+// Bifrost durations are decimal integer counts of microseconds, as emitted by AddDuration.
+func (m *Message) ArgDuration(index int) (time.Duration, error) {
+	const want = "a duration"
+
+	s, err := m.typedArg(index, want)
+	if err != nil {
+		return 0, err
+	}
+	d, err := parseDuration(s)
+	if err != nil {
+		return 0, ArgError{Index: index, Value: s, Want: want, Err: numError(err)}
+	}
+	return d, nil
+}
+
+// parseDuration parses s as a duration argument in microseconds.
+func parseDuration(s string) (time.Duration, error) {
+	us, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if us < math.MinInt64/int64(time.Microsecond) || math.MaxInt64/int64(time.Microsecond) < us {
+		return 0, strconv.ErrRange
+	}
+	return time.Duration(us) * time.Microsecond, nil
+}
+
+// formatDuration formats d as a duration argument in microseconds, truncating any remainder.
+func formatDuration(d time.Duration) string {
+	return strconv.FormatInt(d.Microseconds(), 10)
+}
+
+// ArgEnum gets the index-th argument of m, checking that it is one of allowed.
+func (m *Message) ArgEnum(index int, allowed ...string) (string, error) {
+	want := "one of " + strings.Join(allowed, ", ")
+
+	s, err := m.typedArg(index, want)
+	if err != nil {
+		return "", err
+	}
+	for _, a := range allowed {
+		if s == a {
+			return s, nil
+		}
+	}
+	return "", ArgError{Index: index, Value: s, Want: want}
+}
+
+// AddInt adds the decimal representation of i as an argument to a Message in-place.
+// The given Message-pointer is returned, to allow for chaining.
+func (m *Message) AddInt(i int) *Message {
+	return m.AddArgs(strconv.Itoa(i))
+}
+
+// AddBool adds b as an argument ('true' or 'false') to a Message in-place.
+// The given Message-pointer is returned, to allow for chaining.
+func (m *Message) AddBool(b bool) *Message {
+	return m.AddArgs(strconv.FormatBool(b))
+}
+
+// AddDuration adds d as an argument, as a decimal integer count of microseconds, to a Message in-place.
+// Any part of d smaller than a microsecond is truncated.
+// The given Message-pointer is returned, to allow for chaining.
+func (m *Message) AddDuration(d time.Duration) *Message {
+	return m.AddArgs(formatDuration(d))
+}
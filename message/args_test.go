@@ -0,0 +1,92 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// ExampleMessage_ArgInt is a testable example for ArgInt.
+func ExampleMessage_ArgInt() {
+	m := New(TagBcast, "COUNTL").AddInt(42).AddArgs("lots")
+	fmt.Println(m.ArgInt(0))
+	fmt.Println(m.ArgInt(1))
+	fmt.Println(m.ArgInt(2))
+
+	// Output:
+	// 42 <nil>
+	// 0 argument 1 ("lots") is not an integer: invalid syntax
+	// 0 argument 2 is missing, want an integer
+}
+
+// TestMessage_typedArgs_roundTrip checks that the typed builders and accessors agree with each other.
+func TestMessage_typedArgs_roundTrip(t *testing.T) {
+	m := New("x", "y").AddInt(-7).AddBool(true).AddBool(false).AddDuration(1500 * time.Millisecond)
+
+	if got, err := m.ArgInt(0); err != nil || got != -7 {
+		t.Errorf("ArgInt(0) = %d, %v; want -7", got, err)
+	}
+	if got, err := m.ArgBool(1); err != nil || !got {
+		t.Errorf("ArgBool(1) = %v, %v; want true", got, err)
+	}
+	if got, err := m.ArgBool(2); err != nil || got {
+		t.Errorf("ArgBool(2) = %v, %v; want false", got, err)
+	}
+	if got, err := m.ArgDuration(3); err != nil || got != 1500*time.Millisecond {
+		t.Errorf("ArgDuration(3) = %v, %v; want 1.5s", got, err)
+	}
+}
+
+// ExampleMessage_AddDuration shows that durations go on the wire as integer microseconds.
+func ExampleMessage_AddDuration() {
+	m := New(TagBcast, "POS").AddDuration(2500*time.Millisecond).AddArgs("1.5s", "99999999999999999")
+	fmt.Print(m)
+	fmt.Println(m.ArgDuration(0))
+	fmt.Println(m.ArgDuration(1))
+	fmt.Println(m.ArgDuration(2))
+
+	// Output:
+	// ! POS 2500000 1.5s 99999999999999999
+	// 2.5s <nil>
+	// 0s argument 1 ("1.5s") is not a duration: invalid syntax
+	// 0s argument 2 ("99999999999999999") is not a duration: value out of range
+}
+
+// TestMessage_ArgEnum checks ArgEnum on allowed and disallowed values.
+func TestMessage_ArgEnum(t *testing.T) {
+	m := New("x", "y").AddArgs("next", "sideways")
+	allowed := []string{"off", "drop", "next", "shuffle"}
+
+	if got, err := m.ArgEnum(0, allowed...); err != nil || got != "next" {
+		t.Errorf("ArgEnum(0) = %q, %v; want next", got, err)
+	}
+	if _, err := m.ArgEnum(1, allowed...); err == nil {
+		t.Error("ArgEnum(1) succeeded unexpectedly")
+	}
+}
+
+// TestMessage_typedArgs_errors checks that the typed accessors return ArgErrors for bad arguments.
+func TestMessage_typedArgs_errors(t *testing.T) {
+	m := New("x", "y").AddArgs("nope")
+
+	accessors := map[string]func(int) error{
+		"ArgInt":      func(i int) error { _, err := m.ArgInt(i); return err },
+		"ArgBool":     func(i int) error { _, err := m.ArgBool(i); return err },
+		"ArgDuration": func(i int) error { _, err := m.ArgDuration(i); return err },
+		"ArgEnum":     func(i int) error { _, err := m.ArgEnum(i, "yes"); return err },
+	}
+
+	for name, f := range accessors {
+		for i, missing := range []bool{false, true} {
+			var aerr ArgError
+			if err := f(i); !errors.As(err, &aerr) {
+				t.Errorf("%s(%d) gave error %v; want ArgError", name, i, err)
+			} else if aerr.Index != i {
+				t.Errorf("%s(%d) error has index %d", name, i, aerr.Index)
+			} else if aerr.Missing != missing {
+				t.Errorf("%s(%d) error has Missing=%v; want %v", name, i, aerr.Missing, missing)
+			}
+		}
+	}
+}
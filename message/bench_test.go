@@ -0,0 +1,103 @@
+package message
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+// benchInput is a burst of typical server traffic: position broadcasts, with the odd quoted argument.
+var benchInput = bytes.Repeat([]byte(
+	"! POS 1234567\n"+
+		"! POS 1234568\n"+
+		"f00f ACK OK success\n"+
+		"! FILE '/music/01 The Nightfly.mp3'\n"+
+		"! ITEM 3 abcdef track \"Don't Stop Me Now\"\n"), 200)
+
+var benchMessage = New(TagBcast, "ITEM").AddArgs("3", "abcdef", "track", "/music/01 The Nightfly.mp3")
+
+// BenchmarkTokeniser_TokeniseBytes benchmarks tokenising into strings.
+func BenchmarkTokeniser_TokeniseBytes(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchInput)))
+	t := NewTokeniser()
+	for i := 0; i < b.N; i++ {
+		for in := benchInput; len(in) > 0; {
+			n, _, _, err := t.TokeniseBytes(in)
+			if err != nil {
+				b.Fatal(err)
+			}
+			in = in[n:]
+		}
+	}
+}
+
+// BenchmarkTokeniser_TokeniseBytesView benchmarks tokenising into views.
+func BenchmarkTokeniser_TokeniseBytesView(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchInput)))
+	t := NewTokeniser()
+	for i := 0; i < b.N; i++ {
+		for in := benchInput; len(in) > 0; {
+			n, _, _, err := t.TokeniseBytesView(in)
+			if err != nil {
+				b.Fatal(err)
+			}
+			in = in[n:]
+		}
+	}
+}
+
+// benchReader benchmarks reading all of benchInput with read.
+func benchReader(b *testing.B, read func(*Reader) error) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchInput)))
+	br := bytes.NewReader(benchInput)
+	for i := 0; i < b.N; i++ {
+		br.Reset(benchInput)
+		r := NewReader(ioutil.NopCloser(br))
+		for {
+			if err := read(r); err != nil {
+				break
+			}
+		}
+	}
+}
+
+// BenchmarkReader_ReadLine benchmarks reading lines as strings.
+func BenchmarkReader_ReadLine(b *testing.B) {
+	benchReader(b, func(r *Reader) error {
+		_, err := r.ReadLine()
+		return err
+	})
+}
+
+// BenchmarkReader_ReadLineView benchmarks reading lines as views.
+func BenchmarkReader_ReadLineView(b *testing.B) {
+	benchReader(b, func(r *Reader) error {
+		_, err := r.ReadLineView()
+		return err
+	})
+}
+
+// BenchmarkMessage_Pack benchmarks packing a message into a fresh buffer.
+func BenchmarkMessage_Pack(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := benchMessage.Pack(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMessage_AppendPack benchmarks packing a message into a reused buffer.
+func BenchmarkMessage_AppendPack(b *testing.B) {
+	b.ReportAllocs()
+	var buf []byte
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = benchMessage.AppendPack(buf[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
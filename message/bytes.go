@@ -0,0 +1,88 @@
+package message
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// File message/bytes.go contains the binary argument convention.
+//
+// Binary arguments are encoded as BytesPrefix followed by the standard, padded base64 encoding of the data.
+// The encoded form is plain ASCII without whitespace or quotes, so it survives any Bifrost link unchanged,
+// and the prefix stops it from being confused with a text argument that happens to be valid base64.
+
+// BytesPrefix is the prefix marking an argument as base64-encoded binary data.
+const BytesPrefix = "base64:"
+
+// MaxBytesLength is the largest number of bytes of binary data allowed in one argument.
+// Binary arguments are meant for small payloads, such as thumbnails and signatures;
+// larger data should be sent out of band.
+//
+// The encoded argument is about a third longer than the data, which must be allowed for in any Limits on
+// the receiving Tokeniser.
+const MaxBytesLength = 64 * 1024
+
+var (
+	// ErrNotBytes is the error returned when decoding binary data from an argument without BytesPrefix.
+	ErrNotBytes = errors.New("missing " + BytesPrefix + " prefix")
+	// ErrBytesTooLong is the error returned when encoding or decoding binary data longer than MaxBytesLength.
+	ErrBytesTooLong = fmt.Errorf("binary data longer than %d bytes", MaxBytesLength)
+)
+
+// EncodeBytes encodes b as a binary argument.
+// Use it to put binary data where AddBytes can't, such as in named arguments.
+func EncodeBytes(b []byte) string {
+	return BytesPrefix + base64.StdEncoding.EncodeToString(b)
+}
+
+// DecodeBytes decodes binary data from the binary argument a.
+// It fails with ErrNotBytes if a doesn't start with BytesPrefix, and ErrBytesTooLong if the data is longer than
+// MaxBytesLength.
+func DecodeBytes(a string) ([]byte, error) {
+	if !strings.HasPrefix(a, BytesPrefix) {
+		return nil, ErrNotBytes
+	}
+	enc := a[len(BytesPrefix):]
+
+	// Check the length before decoding, so that oversized arguments don't cost an allocation.
+	if base64.StdEncoding.EncodedLen(MaxBytesLength) < len(enc) {
+		return nil, ErrBytesTooLong
+	}
+	b, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return nil, err
+	}
+	if MaxBytesLength < len(b) {
+		return nil, ErrBytesTooLong
+	}
+	return b, nil
+}
+
+// AddBytes adds b as a binary argument to a Message in-place.
+// The given Message-pointer is returned.
+//
+// AddBytes fails with ErrBytesTooLong, leaving m unchanged, if b is longer than MaxBytesLength,
+// as receivers would reject it.
+func (m *Message) AddBytes(b []byte) (*Message, error) {
+	if MaxBytesLength < len(b) {
+		return m, ErrBytesTooLong
+	}
+	return m.AddArgs(EncodeBytes(b)), nil
+}
+
+// ArgBytes gets the index-th argument of m as binary data.
+func (m *Message) ArgBytes(index int) ([]byte, error) {
+	const want = "binary data"
+
+	s, err := m.typedArg(index, want)
+	if err != nil {
+		return nil, err
+	}
+	b, err := DecodeBytes(s)
+	if err != nil {
+		return nil, ArgError{Index: index, Value: s, Want: want, Err: err}
+	}
+	return b, nil
+}
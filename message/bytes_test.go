@@ -0,0 +1,80 @@
+package message
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ExampleMessage_AddBytes is a testable example for AddBytes.
+func ExampleMessage_AddBytes() {
+	m, _ := New(TagBcast, "PEAKS").AddBytes([]byte{0, 64, 128, 255})
+	fmt.Print(m)
+
+	b, err := m.ArgBytes(0)
+	fmt.Println(b, err)
+
+	_, err = m.AddBytes(make([]byte, MaxBytesLength+1))
+	fmt.Println(err)
+
+	// Output:
+	// ! PEAKS base64:AECA/w==
+	// [0 64 128 255] <nil>
+	// binary data longer than 65536 bytes
+}
+
+// TestMessage_ArgBytes_roundTrip checks that binary data survives packing, unpacking, and ArgBytes.
+func TestMessage_ArgBytes_roundTrip(t *testing.T) {
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	cases := [][]byte{{}, {'\n'}, []byte("'\"\\ "), all, make([]byte, MaxBytesLength)}
+
+	for _, want := range cases {
+		m, err := New("x", "y").AddBytes(want)
+		if err != nil {
+			t.Fatalf("AddBytes gave error %v", err)
+		}
+		packed, err := m.Pack()
+		if err != nil {
+			t.Fatalf("Pack gave error %v", err)
+		}
+		m, err = Unpack(packed)
+		if err != nil {
+			t.Fatalf("Unpack gave error %v", err)
+		}
+		if got, err := m.ArgBytes(0); err != nil {
+			t.Errorf("ArgBytes gave error %v", err)
+		} else if !bytes.Equal(got, want) {
+			t.Errorf("ArgBytes = %v; want %v", got, want)
+		}
+	}
+}
+
+// TestMessage_ArgBytes_errors checks that ArgBytes rejects arguments that aren't valid binary data.
+func TestMessage_ArgBytes_errors(t *testing.T) {
+	cases := []struct {
+		arg  string
+		want error
+	}{
+		{"AQ==", ErrNotBytes},
+		{"base64:AQ=", nil},
+		{"base64:!!!!", nil},
+		{BytesPrefix + strings.Repeat("A", 4*(MaxBytesLength/3+1)), ErrBytesTooLong},
+		{EncodeBytes(make([]byte, MaxBytesLength+1)), ErrBytesTooLong},
+	}
+
+	for _, c := range cases {
+		_, err := New("x", "y").AddArgs(c.arg).ArgBytes(0)
+
+		var aerr ArgError
+		if !errors.As(err, &aerr) {
+			t.Errorf("ArgBytes(%.20q...) gave error %v; want ArgError", c.arg, err)
+		} else if c.want != nil && !errors.Is(err, c.want) {
+			t.Errorf("ArgBytes(%.20q...) gave error %v; want %v", c.arg, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package message
+
+// File message/input_mode.go contains the input pre-processing done by Tokenisers before tokenising.
+
+// InputMode is a set of flags controlling how a Tokeniser pre-processes its input.
+// The zero InputMode tokenises input exactly as it arrives.
+type InputMode int
+
+const (
+	// ModeCRLF normalises "\r\n" line endings to "\n", including inside quotes.
+	// Carriage returns not followed by a line feed are left alone.
+	ModeCRLF InputMode = 1 << iota
+
+	// ModeTelnet strips telnet IAC command and option negotiation sequences, and unescapes doubled IAC bytes.
+	// With ModeCRLF, it also treats telnet's "\r\x00" as a bare carriage return.
+	ModeTelnet
+
+	// ModeInteractive is the InputMode for input from interactive tools such as telnet and netcat.
+	ModeInteractive = ModeCRLF | ModeTelnet
+)
+
+// Telnet command bytes; see RFC 854.
+const (
+	telnetSE   byte = 240
+	telnetSB   byte = 250
+	telnetWILL byte = 251
+	telnetDONT byte = 254
+	telnetIAC  byte = 255
+)
+
+// telnetState is the enumeration of states of the telnet IAC filter.
+type telnetState int
+
+const (
+	// telnetData represents ordinary data.
+	telnetData telnetState = iota
+	// telnetCommand represents the byte just after an IAC.
+	telnetCommand
+	// telnetOption represents the option byte after an IAC WILL/WONT/DO/DONT.
+	telnetOption
+	// telnetSub represents the inside of an IAC SB ... IAC SE subnegotiation.
+	telnetSub
+	// telnetSubCommand represents the byte just after an IAC inside a subnegotiation.
+	telnetSubCommand
+)
+
+// inputFilter holds the state of a Tokeniser's input pre-processing.
+type inputFilter struct {
+	mode   InputMode
+	telnet telnetState
+	// heldCR is true if the filter has read a carriage return, and is waiting to see if a line feed follows.
+	heldCR bool
+	// out holds the filtered bytes for the current input byte.
+	out [2]byte
+}
+
+// filter pre-processes the input byte b, returning the zero, one, or two bytes it turns into.
+// The returned slice is only valid until the next call.
+func (f *inputFilter) filter(b byte) []byte {
+	if f.mode&ModeTelnet != 0 {
+		var ok bool
+		if b, ok = f.filterTelnet(b); !ok {
+			return nil
+		}
+	}
+	if f.mode&ModeCRLF != 0 {
+		return f.filterCRLF(b)
+	}
+	f.out[0] = b
+	return f.out[:1]
+}
+
+// filterTelnet passes b through the telnet IAC filter.
+// It returns the byte to keep, and whether there is one.
+func (f *inputFilter) filterTelnet(b byte) (byte, bool) {
+	switch f.telnet {
+	case telnetCommand:
+		switch {
+		case b == telnetIAC:
+			// IAC IAC is an escaped 255 data byte.
+			f.telnet = telnetData
+			return b, true
+		case b == telnetSB:
+			f.telnet = telnetSub
+		case telnetWILL <= b && b <= telnetDONT:
+			f.telnet = telnetOption
+		default:
+			f.telnet = telnetData
+		}
+	case telnetOption:
+		f.telnet = telnetData
+	case telnetSub:
+		if b == telnetIAC {
+			f.telnet = telnetSubCommand
+		}
+	case telnetSubCommand:
+		if b == telnetSE {
+			f.telnet = telnetData
+		} else {
+			f.telnet = telnetSub
+		}
+	default:
+		if b == telnetIAC {
+			f.telnet = telnetCommand
+		} else {
+			return b, true
+		}
+	}
+	return 0, false
+}
+
+// filterCRLF passes b through the CRLF normaliser.
+func (f *inputFilter) filterCRLF(b byte) []byte {
+	if !f.heldCR {
+		if b == '\r' {
+			f.heldCR = true
+			return nil
+		}
+		f.out[0] = b
+		return f.out[:1]
+	}
+
+	f.heldCR = false
+	switch {
+	case b == '\n':
+		f.out[0] = '\n'
+		return f.out[:1]
+	case b == 0 && f.mode&ModeTelnet != 0:
+		f.out[0] = '\r'
+		return f.out[:1]
+	case b == '\r':
+		f.heldCR = true
+		f.out[0] = '\r'
+		return f.out[:1]
+	default:
+		f.out[0], f.out[1] = '\r', b
+		return f.out[:2]
+	}
+}
@@ -0,0 +1,83 @@
+package message
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// readAllLines reads every line from in using a Reader in mode m.
+func readAllLines(t *testing.T, m InputMode, in string) [][]string {
+	t.Helper()
+
+	r := NewReader(ioutil.NopCloser(bytes.NewReader([]byte(in))))
+	r.SetMode(m)
+
+	var got [][]string
+	for {
+		line, err := r.ReadLine()
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Errorf("ReadLine(%q) in mode %d gave error %v", in, m, err)
+			return got
+		}
+		got = append(got, line)
+	}
+}
+
+// TestReader_SetMode checks that input modes pre-process input correctly.
+func TestReader_SetMode(t *testing.T) {
+	cases := []struct {
+		mode InputMode
+		in   string
+		want [][]string
+	}{
+		// Without ModeCRLF, carriage returns inside quotes are kept.
+		{0, "'silly windows'\r\n", [][]string{{"silly windows"}}},
+		{0, "'silly\r\nwindows'\r\n", [][]string{{"silly\r\nwindows"}}},
+		{ModeCRLF, "'silly\r\nwindows'\r\n", [][]string{{"silly\nwindows"}}},
+		{ModeCRLF, "\"silly\r\nwindows\"\r\n", [][]string{{"silly\nwindows"}}},
+		// Bare carriage returns survive ModeCRLF.
+		{ModeCRLF, "'a\rb' 'c\r\r\nd'\r\n", [][]string{{"a\rb", "c\r\nd"}}},
+		// Telnet negotiation: IAC DO ECHO, IAC SB ... IAC SE, IAC NOP.
+		{ModeTelnet, "\xff\xfd\x01foo \xff\xfa\x18\x01\xff\xf0bar\xff\xf1\n", [][]string{{"foo", "bar"}}},
+		// Telnet escaped IAC.
+		{ModeTelnet, "'\xff\xff'\n", [][]string{{"\xff"}}},
+		// Telnet CR NUL.
+		{ModeInteractive, "'a\r\x00b'\r\n", [][]string{{"a\rb"}}},
+		{ModeInteractive, "\xff\xfb\x03'x\r\ny'\r\nz\r\n", [][]string{{"x\ny"}, {"z"}}},
+	}
+
+	for _, c := range cases {
+		got := readAllLines(t, c.mode, c.in)
+		if !cmpLines(got, c.want) {
+			t.Errorf("ReadLine(%q) in mode %d == %q, want %q", c.in, c.mode, got, c.want)
+		}
+	}
+}
+
+// TestTokeniser_SetMode_split checks that CRLF normalisation works when the CR and LF arrive separately.
+func TestTokeniser_SetMode_split(t *testing.T) {
+	tok := NewTokeniser()
+	tok.SetMode(ModeCRLF)
+
+	if _, lineok, _, err := tok.TokeniseBytes([]byte("'foo\r")); lineok || err != nil {
+		t.Fatalf("unexpected line end or error: %v", err)
+	}
+	if _, lineok, _, err := tok.TokeniseBytes([]byte("\nbar'\r")); lineok || err != nil {
+		t.Fatalf("unexpected line end or error: %v", err)
+	}
+	_, lineok, line, err := tok.TokeniseBytes([]byte("\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !lineok {
+		t.Fatal("expected line end")
+	}
+	if want := []string{"foo\nbar"}; !cmpWords(line, want) {
+		t.Errorf("got %q; want %q", line, want)
+	}
+}
@@ -0,0 +1,148 @@
+package message
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// File message/json.go contains the JSON representation of Messages, and JSON-lines readers and writers for them.
+//
+// A Message is represented as an object with "tag", "word", and "args" fields:
+//
+//	{"tag":"!","word":"OHAI","args":["bifrost-0.0.0","example-1.0.0"]}
+//
+// Arguments that aren't valid UTF-8, and so can't be JSON strings, are represented as {"base64":"..."} objects.
+
+// jsonMessage is the JSON representation of a Message.
+type jsonMessage struct {
+	Tag  *string           `json:"tag"`
+	Word *string           `json:"word"`
+	Args []json.RawMessage `json:"args"`
+}
+
+// jsonBinaryArg is the JSON representation of an argument that isn't valid UTF-8.
+type jsonBinaryArg struct {
+	Base64 *string `json:"base64"`
+}
+
+// MarshalJSON implements json.Marshaler for Message.
+func (m Message) MarshalJSON() ([]byte, error) {
+	if !utf8.ValidString(m.tag) {
+		return nil, errors.New("tag is not valid UTF-8")
+	}
+	if !utf8.ValidString(m.word) {
+		return nil, errors.New("word is not valid UTF-8")
+	}
+
+	jm := jsonMessage{Tag: &m.tag, Word: &m.word, Args: make([]json.RawMessage, len(m.args))}
+	for i, a := range m.args {
+		var err error
+		if jm.Args[i], err = marshalJSONArg(a); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(jm)
+}
+
+// marshalJSONArg converts an argument to JSON.
+func marshalJSONArg(a string) (json.RawMessage, error) {
+	if utf8.ValidString(a) {
+		return json.Marshal(a)
+	}
+	enc := base64.StdEncoding.EncodeToString([]byte(a))
+	return json.Marshal(jsonBinaryArg{Base64: &enc})
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Message.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var jm jsonMessage
+	if err := json.Unmarshal(data, &jm); err != nil {
+		return err
+	}
+	if jm.Tag == nil {
+		return errors.New("message JSON has no tag")
+	}
+	if jm.Word == nil {
+		return errors.New("message JSON has no word")
+	}
+
+	var args []string
+	if len(jm.Args) != 0 {
+		args = make([]string, len(jm.Args))
+	}
+	for i, ja := range jm.Args {
+		var err error
+		if args[i], err = unmarshalJSONArg(ja); err != nil {
+			return fmt.Errorf("argument %d: %w", i, err)
+		}
+	}
+
+	*m = Message{tag: *jm.Tag, word: *jm.Word, args: args}
+	return nil
+}
+
+// unmarshalJSONArg converts an argument from JSON.
+func unmarshalJSONArg(ja json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(ja, &s); err == nil {
+		return s, nil
+	}
+
+	var b jsonBinaryArg
+	if err := json.Unmarshal(ja, &b); err != nil {
+		return "", err
+	}
+	if b.Base64 == nil {
+		return "", errors.New("argument is neither a string nor a base64 object")
+	}
+	dec, err := base64.StdEncoding.DecodeString(*b.Base64)
+	return string(dec), err
+}
+
+// JSONReader wraps a ReadCloser to read Messages from a stream of JSON values, such as a JSON-lines file.
+type JSONReader struct {
+	dec    *json.Decoder
+	reader io.ReadCloser
+}
+
+// NewJSONReader creates and returns a new JSONReader.
+// If closing is not required, use ioutil.NopCloser.
+func NewJSONReader(reader io.ReadCloser) *JSONReader {
+	return &JSONReader{dec: json.NewDecoder(reader), reader: reader}
+}
+
+// ReadMessage reads a Message from the JSONReader.
+// It returns io.EOF at the end of the stream.
+func (r *JSONReader) ReadMessage() (*Message, error) {
+	var m Message
+	if err := r.dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Close closes the JSONReader's underlying ReadCloser.
+func (r *JSONReader) Close() error {
+	return r.reader.Close()
+}
+
+// JSONWriter wraps a Writer to write Messages as JSON lines.
+type JSONWriter struct {
+	enc *json.Encoder
+}
+
+// NewJSONWriter creates and returns a new JSONWriter.
+func NewJSONWriter(w io.Writer) *JSONWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONWriter{enc: enc}
+}
+
+// WriteMessage writes m to the JSONWriter as one line of JSON.
+func (w *JSONWriter) WriteMessage(m *Message) error {
+	return w.enc.Encode(m)
+}
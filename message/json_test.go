@@ -0,0 +1,97 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+// ExampleMessage_MarshalJSON is a testable example for MarshalJSON.
+func ExampleMessage_MarshalJSON() {
+	m := New(TagBcast, "OHAI").AddArgs("bifrost-0.0.0", "", "bin\xff")
+	if b, err := json.Marshal(m); err != nil {
+		fmt.Println("error:", err)
+	} else {
+		fmt.Println(string(b))
+	}
+
+	// Output:
+	// {"tag":"!","word":"OHAI","args":["bifrost-0.0.0","",{"base64":"Ymlu/w=="}]}
+}
+
+var jsonRoundTripCases = []*Message{
+	New("x", "write"),
+	New(TagBcast, "OHAI").AddArgs("bifrost-0.0.0", "example-1.0.0"),
+	New("f00f", "ACK").AddArgs("", "<html> & \"quotes\"", "multi\nline"),
+	New(TagUnknown, "blob").AddArgs("\x00\xff\xfe", "北野 武"),
+}
+
+// TestMessage_JSON_roundTrip checks that unmarshalling a marshalled Message gives the same Message.
+func TestMessage_JSON_roundTrip(t *testing.T) {
+	for _, want := range jsonRoundTripCases {
+		b, err := json.Marshal(want)
+		if err != nil {
+			t.Errorf("marshal %s: %v", want, err)
+			continue
+		}
+
+		var got Message
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Errorf("unmarshal %s: %v", b, err)
+		} else if !reflect.DeepEqual(got.Args(), want.Args()) || got.Tag() != want.Tag() || got.Word() != want.Word() {
+			t.Errorf("round trip of %q gave %q", want, &got)
+		}
+	}
+}
+
+// TestMessage_UnmarshalJSON_errors checks that UnmarshalJSON rejects bad JSON messages.
+func TestMessage_UnmarshalJSON_errors(t *testing.T) {
+	cases := []string{
+		`{"word":"OHAI","args":[]}`,
+		`{"tag":"!","args":[]}`,
+		`{"tag":"!","word":"OHAI","args":[1]}`,
+		`{"tag":"!","word":"OHAI","args":[{"base64":"!!!"}]}`,
+		`{"tag":"!","word":"OHAI","args":[{}]}`,
+		`["!","OHAI"]`,
+	}
+
+	for _, c := range cases {
+		var m Message
+		if err := json.Unmarshal([]byte(c), &m); err == nil {
+			t.Errorf("unmarshal of %s succeeded unexpectedly", c)
+		}
+	}
+}
+
+// TestJSONReader_JSONWriter checks that a JSONReader reads back what a JSONWriter writes.
+func TestJSONReader_JSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONWriter(&buf)
+	for _, m := range jsonRoundTripCases {
+		if err := w.WriteMessage(m); err != nil {
+			t.Fatalf("write %s: %v", m, err)
+		}
+	}
+
+	if lines := bytes.Count(buf.Bytes(), []byte("\n")); lines != len(jsonRoundTripCases) {
+		t.Errorf("wrote %d lines; want %d", lines, len(jsonRoundTripCases))
+	}
+
+	r := NewJSONReader(ioutil.NopCloser(&buf))
+	for _, want := range jsonRoundTripCases {
+		got, err := r.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if !reflect.DeepEqual(got.Args(), want.Args()) || got.Tag() != want.Tag() || got.Word() != want.Word() {
+			t.Errorf("read %q; want %q", got, want)
+		}
+	}
+	if _, err := r.ReadMessage(); err != io.EOF {
+		t.Errorf("read at end gave %v; want EOF", err)
+	}
+}
@@ -0,0 +1,315 @@
+package message
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File message/marshal.go contains reflection-based conversion between Go structs and Messages.
+
+// Marshal converts the struct (or pointer to struct) v into a Message with the given tag and word.
+//
+// Each exported field of v becomes one positional argument, in field order.
+// Fields may be strings, Booleans, integers, time.Durations, byte slices, or types implementing
+// encoding.TextMarshaler.
+// Booleans, durations, and byte slices use the same syntax as AddBool, AddDuration, and AddBytes.
+// The last field may also be a slice of any of these, in which case it becomes zero or more trailing arguments.
+//
+// The 'bifrost' struct tag controls how fields are mapped:
+//
+//	// Field is ignored.
+//	Field string `bifrost:"-"`
+//	// Field is optional, and omitted when it and all later fields are zero.
+//	Field string `bifrost:"optional"`
+//
+// Optional fields must come after all required fields.
+func Marshal(tag, word string, v interface{}) (*Message, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return nil, fmt.Errorf("can't marshal nil %T", v)
+	}
+	rv = reflect.Indirect(rv)
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	m := New(tag, word)
+
+	// Work out which trailing optional (or empty variadic) fields we can leave off.
+	end := len(fields)
+	for end > 0 && fields[end-1].omittable(rv.Field(fields[end-1].index)) {
+		end--
+	}
+
+	for _, f := range fields[:end] {
+		fv := rv.Field(f.index)
+		if !f.variadic {
+			s, err := encodeArg(fv)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.name, err)
+			}
+			m.AddArgs(s)
+			continue
+		}
+
+		for i := 0; i < fv.Len(); i++ {
+			s, err := encodeArg(fv.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("field %s[%d]: %w", f.name, i, err)
+			}
+			m.AddArgs(s)
+		}
+	}
+
+	return m, nil
+}
+
+// Unmarshal converts the arguments of m into the struct pointed to by v.
+// It uses the same field mapping as Marshal, and ignores m's tag and word.
+//
+// If m has the wrong number of arguments, Unmarshal returns an ArityError.
+// If an argument can't be converted to the type of its field, Unmarshal returns an ArgError.
+func Unmarshal(m *Message, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("can't unmarshal into non-pointer %T", v)
+	}
+	rv = rv.Elem()
+
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		return err
+	}
+	if err := checkFieldArity(fields, len(m.args)); err != nil {
+		return err
+	}
+
+	for i, f := range fields {
+		if len(m.args) <= i {
+			// Missing optional fields are left alone.
+			break
+		}
+
+		fv := rv.Field(f.index)
+		if !f.variadic {
+			if err := decodeArg(m.args[i], i, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		rest := m.args[i:]
+		fv.Set(reflect.MakeSlice(fv.Type(), len(rest), len(rest)))
+		for j, a := range rest {
+			if err := decodeArg(a, i+j, fv.Index(j)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkFieldArity checks that nargs arguments are enough, and not too many, for fields.
+func checkFieldArity(fields []structField, nargs int) error {
+	min, max := 0, len(fields)
+	for _, f := range fields {
+		if f.variadic {
+			max = -1
+		} else if !f.optional {
+			min++
+		}
+	}
+
+	if nargs < min || (0 <= max && max < nargs) {
+		return ArityError{Got: nargs, Min: min, Max: max}
+	}
+	return nil
+}
+
+// structField describes how a struct field maps onto message arguments.
+type structField struct {
+	name     string
+	index    int
+	optional bool
+	variadic bool
+}
+
+// omittable checks whether a field with value v can be left off the end of a message.
+func (f structField) omittable(v reflect.Value) bool {
+	if f.variadic {
+		return v.Len() == 0
+	}
+	return f.optional && v.IsZero()
+}
+
+// structFields works out the argument mapping for the struct type t.
+func structFields(t reflect.Type) ([]structField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can't map %s onto message arguments", t)
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			// Unexported.
+			continue
+		}
+
+		f := structField{name: sf.Name, index: i}
+		skip := false
+		for _, opt := range strings.Split(sf.Tag.Get("bifrost"), ",") {
+			switch opt {
+			case "":
+			case "-":
+				skip = true
+			case "optional":
+				f.optional = true
+			default:
+				return nil, fmt.Errorf("field %s: unknown bifrost tag option %q", sf.Name, opt)
+			}
+		}
+		if skip {
+			continue
+		}
+
+		if sf.Type.Kind() == reflect.Slice && !isArgType(sf.Type) {
+			f.variadic = true
+			if !isArgType(sf.Type.Elem()) {
+				return nil, fmt.Errorf("field %s: unsupported element type %s", sf.Name, sf.Type.Elem())
+			}
+		} else if !isArgType(sf.Type) {
+			return nil, fmt.Errorf("field %s: unsupported type %s", sf.Name, sf.Type)
+		}
+
+		if err := checkFieldOrder(fields, f); err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// checkFieldOrder checks that f can follow fields.
+func checkFieldOrder(fields []structField, f structField) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	last := fields[len(fields)-1]
+	if last.variadic {
+		return fmt.Errorf("field %s follows variadic field %s", f.name, last.name)
+	}
+	if last.optional && !f.optional && !f.variadic {
+		return fmt.Errorf("required field %s follows optional field %s", f.name, last.name)
+	}
+	return nil
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	bytesType           = reflect.TypeOf([]byte(nil))
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isArgType checks whether t can be converted to and from a single argument.
+func isArgType(t reflect.Type) bool {
+	if t.Implements(textMarshalerType) && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	if t == bytesType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// encodeArg converts the value v into an argument.
+func encodeArg(v reflect.Value) (string, error) {
+	if tm, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	if v.Type() == durationType {
+		return formatDuration(time.Duration(v.Int())), nil
+	}
+	if v.Type() == bytesType {
+		if MaxBytesLength < v.Len() {
+			return "", ErrBytesTooLong
+		}
+		return EncodeBytes(v.Bytes()), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	default:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	}
+}
+
+// decodeArg converts the index-th argument a into v.
+func decodeArg(a string, index int, v reflect.Value) error {
+	if tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := tu.UnmarshalText([]byte(a)); err != nil {
+			return ArgError{Index: index, Value: a, Want: "a valid " + v.Type().String(), Err: err}
+		}
+		return nil
+	}
+
+	if v.Type() == durationType {
+		d, err := parseDuration(a)
+		if err != nil {
+			return ArgError{Index: index, Value: a, Want: "a duration", Err: numError(err)}
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	if v.Type() == bytesType {
+		b, err := DecodeBytes(a)
+		if err != nil {
+			return ArgError{Index: index, Value: a, Want: "binary data", Err: err}
+		}
+		v.SetBytes(b)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(a)
+	case reflect.Bool:
+		b, ok := parseBool(a)
+		if !ok {
+			return ArgError{Index: index, Value: a, Want: "a Boolean"}
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(a, 10, v.Type().Bits())
+		if err != nil {
+			return ArgError{Index: index, Value: a, Want: "an integer", Err: numError(err)}
+		}
+		v.SetInt(i)
+	default:
+		u, err := strconv.ParseUint(a, 10, v.Type().Bits())
+		if err != nil {
+			return ArgError{Index: index, Value: a, Want: "a non-negative integer", Err: numError(err)}
+		}
+		v.SetUint(u)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// seekResponse is an example response type for testing Marshal and Unmarshal.
+type seekResponse struct {
+	File     string
+	Pos      time.Duration
+	Loaded   bool
+	Track    uint8
+	Comment  string `bifrost:"optional"`
+	internal int
+	Ignored  string `bifrost:"-"`
+}
+
+// tagsResponse is an example response type with variadic arguments.
+type tagsResponse struct {
+	Count int
+	Tags  []string
+}
+
+// artResponse is an example response type with binary arguments.
+type artResponse struct {
+	Thumb []byte
+	Sigs  [][]byte
+}
+
+// ExampleMarshal is a testable example for Marshal.
+func ExampleMarshal() {
+	r := struct {
+		ProtocolVer string
+		ServerVer   string
+	}{"bifrost-0.0.0", "example-1.0.0"}
+
+	if m, err := Marshal(TagBcast, "OHAI", r); err != nil {
+		fmt.Println("error:", err)
+	} else {
+		fmt.Print(m)
+	}
+
+	// Output:
+	// ! OHAI bifrost-0.0.0 example-1.0.0
+}
+
+// ExampleUnmarshal is a testable example for Unmarshal.
+func ExampleUnmarshal() {
+	var r struct {
+		Role string
+	}
+
+	m := New(TagBcast, "IAMA").AddArgs("player/file")
+	if err := Unmarshal(m, &r); err != nil {
+		fmt.Println("error:", err)
+	} else {
+		fmt.Println("Role:", r.Role)
+	}
+
+	m = New(TagBcast, "IAMA")
+	fmt.Println(Unmarshal(m, &r))
+
+	// Output:
+	// Role: player/file
+	// message has 0 arguments, want 1
+}
+
+// TestMarshal_roundTrip checks that Unmarshal undoes Marshal.
+func TestMarshal_roundTrip(t *testing.T) {
+	cases := []struct {
+		in       interface{}
+		wantArgs []string
+	}{
+		{
+			&seekResponse{File: "a b.mp3", Pos: 90 * time.Second, Loaded: true, Track: 3, Comment: "hi"},
+			[]string{"a b.mp3", "90000000", "true", "3", "hi"},
+		},
+		{
+			&seekResponse{File: "c.mp3", Pos: time.Millisecond},
+			[]string{"c.mp3", "1000", "false", "0"},
+		},
+		{
+			&tagsResponse{Count: 2, Tags: []string{"rock", "pop"}},
+			[]string{"2", "rock", "pop"},
+		},
+		{
+			&tagsResponse{Count: 0},
+			[]string{"0"},
+		},
+		{
+			&artResponse{Thumb: []byte{0x89, 'P', 'N', 'G'}, Sigs: [][]byte{{1}, {2, 3}}},
+			[]string{"base64:iVBORw==", "base64:AQ==", "base64:AgM="},
+		},
+	}
+
+	for _, c := range cases {
+		m, err := Marshal("t", "W", c.in)
+		if err != nil {
+			t.Errorf("Marshal(%v) gave error %v", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(m.Args(), c.wantArgs) {
+			t.Errorf("Marshal(%v) gave args %q; want %q", c.in, m.Args(), c.wantArgs)
+		}
+
+		got := reflect.New(reflect.TypeOf(c.in).Elem())
+		if err := Unmarshal(m, got.Interface()); err != nil {
+			t.Errorf("Unmarshal(%s) gave error %v", m, err)
+		} else if !reflect.DeepEqual(got.Interface(), c.in) {
+			t.Errorf("Unmarshal(%s) = %v; want %v", m, got.Interface(), c.in)
+		}
+	}
+}
+
+// TestUnmarshal_errors checks that Unmarshal reports bad arguments.
+func TestUnmarshal_errors(t *testing.T) {
+	cases := []struct {
+		args  []string
+		arity *ArityError
+		index int
+	}{
+		{[]string{"f"}, &ArityError{Got: 1, Min: 4, Max: 5}, 0},
+		{[]string{"f", "1000000", "true", "3", "hi", "extra"}, &ArityError{Got: 6, Min: 4, Max: 5}, 0},
+		{[]string{"f", "later", "true", "3"}, nil, 1},
+		{[]string{"f", "1000000", "maybe", "3"}, nil, 2},
+		{[]string{"f", "1000000", "true", "256"}, nil, 3},
+		{[]string{"f", "1000000", "true", "-1"}, nil, 3},
+	}
+
+	for _, c := range cases {
+		var r seekResponse
+		err := Unmarshal(New("t", "W").AddArgs(c.args...), &r)
+
+		var (
+			rerr ArityError
+			aerr ArgError
+		)
+		if c.arity != nil {
+			if !errors.As(err, &rerr) {
+				t.Errorf("Unmarshal(%q) gave error %v; want ArityError", c.args, err)
+			} else if rerr != *c.arity {
+				t.Errorf("Unmarshal(%q) gave error %v; want %v", c.args, rerr, *c.arity)
+			}
+		} else if !errors.As(err, &aerr) {
+			t.Errorf("Unmarshal(%q) gave error %v; want ArgError", c.args, err)
+		} else if aerr.Index != c.index {
+			t.Errorf("Unmarshal(%q) blamed argument %d; want %d", c.args, aerr.Index, c.index)
+		}
+	}
+}
+
+// TestMarshal_badStructs checks that Marshal and Unmarshal reject structs they can't map.
+func TestMarshal_badStructs(t *testing.T) {
+	cases := []interface{}{
+		&struct{ F float64 }{},
+		&struct {
+			A string `bifrost:"optional"`
+			B string
+		}{},
+		&struct {
+			A []string
+			B string
+		}{},
+		&struct {
+			A string `bifrost:"sometimes"`
+		}{},
+	}
+
+	for _, c := range cases {
+		if _, err := Marshal("t", "W", c); err == nil {
+			t.Errorf("Marshal(%T) succeeded unexpectedly", c)
+		}
+		if err := Unmarshal(New("t", "W"), c); err == nil {
+			t.Errorf("Unmarshal(%T) succeeded unexpectedly", c)
+		}
+	}
+}
+
+// TestMarshal_nil checks that Marshal rejects nil values, rather than panicking.
+func TestMarshal_nil(t *testing.T) {
+	cases := []interface{}{
+		nil,
+		(*struct{ A string })(nil),
+	}
+
+	for _, c := range cases {
+		if _, err := Marshal("t", "W", c); err == nil {
+			t.Errorf("Marshal(%#v) succeeded unexpectedly", c)
+		}
+	}
+}
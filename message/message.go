@@ -1,10 +1,8 @@
 package message
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
-	"strings"
-	"unicode"
 )
 
 // Message is a structure representing a full Bifrost message.
@@ -32,44 +30,134 @@ func (m *Message) AddArgs(args ...string) *Message {
 	return m
 }
 
-// escapeArgument escapes a message argument.
-// It does so using Bifrost's single-quoting, which is easy to encode but bad for human readability.
-func escapeArgument(input string) string {
-	return "'" + strings.Replace(input, "'", `'\''`, -1) + "'"
-}
+// QuoteStyle is the enumeration of ways Pack can quote arguments.
+// Whichever style is used, the Tokeniser parses the packed message back into the same arguments.
+type QuoteStyle int
+
+const (
+	// QuoteSingle quotes arguments only when needed, using single quotes.
+	// This is Pack's default: it is easy to encode, but embedded single quotes become hard to read.
+	QuoteSingle QuoteStyle = iota
+
+	// QuoteMinimal quotes arguments only when needed, using whichever of single or double quotes is shorter.
+	QuoteMinimal
+
+	// QuoteDouble quotes arguments only when needed, using double quotes and backslash escapes.
+	QuoteDouble
+
+	// QuoteAlways quotes every argument, using single quotes.
+	QuoteAlways
+)
 
 // Pack outputs the given Message as raw bytes representing a Bifrost message.
 // These bytes can be sent down a TCP connection to a Bifrost server, providing
 // they are terminated using a line-feed character.
+//
+// Pack fails with a ValidationError if the tag or word would corrupt the packed line;
+// see Validate.
 func (m *Message) Pack() ([]byte, error) {
-	buf, err := m.packToBuffer()
-	if err != nil {
-		return []byte{}, err
+	return m.AppendPackWith(nil, QuoteSingle)
+}
+
+// PackWith is like Pack, but quotes arguments using style.
+func (m *Message) PackWith(style QuoteStyle) ([]byte, error) {
+	return m.AppendPackWith(nil, style)
+}
+
+// AppendPack is like Pack, but appends the packed message to dst and returns the extended buffer.
+// Reusing dst across calls avoids allocating.
+func (m *Message) AppendPack(dst []byte) ([]byte, error) {
+	return m.AppendPackWith(dst, QuoteSingle)
+}
+
+// AppendPackWith is like AppendPack, but quotes arguments using style.
+func (m *Message) AppendPackWith(dst []byte, style QuoteStyle) ([]byte, error) {
+	if err := m.checkWellFormed(); err != nil {
+		return dst, err
 	}
-	return buf.Bytes(), nil
+	return m.appendPack(dst, style), nil
 }
 
-func (m *Message) packToBuffer() (*bytes.Buffer, error) {
-	output := bytes.NewBufferString(m.tag + " " + m.word)
+// appendPack appends the packed message to dst without checking it.
+func (m *Message) appendPack(dst []byte, style QuoteStyle) []byte {
+	dst = append(dst, m.tag...)
+	dst = append(dst, ' ')
+	dst = append(dst, m.word...)
 
 	for _, a := range m.args {
-		a = m.escapeArgIfNeeded(a)
+		dst = append(dst, ' ')
+		dst = appendArg(dst, a, style)
+	}
+	return append(dst, '\n')
+}
+
+// appendArg appends a message argument to dst, quoting it according to style.
+func appendArg(dst []byte, a string, style QuoteStyle) []byte {
+	if style != QuoteAlways && !needsEscape(a) {
+		return append(dst, a...)
+	}
+
+	if style == QuoteDouble || (style == QuoteMinimal && preferDouble(a)) {
+		return appendDoubleQuoted(dst, a)
+	}
+	return appendSingleQuoted(dst, a)
+}
 
-		if _, err := output.WriteString(" " + a); err != nil {
-			return output, err
+// appendSingleQuoted appends a to dst in single quotes.
+// Single quotes can't contain single quotes, so we close the quotes, add an escaped quote, and reopen them.
+func appendSingleQuoted(dst []byte, a string) []byte {
+	dst = append(dst, '\'')
+	for i := 0; i < len(a); i++ {
+		if a[i] == '\'' {
+			dst = append(dst, `'\''`...)
+		} else {
+			dst = append(dst, a[i])
 		}
 	}
-	_, err := output.WriteRune('\n')
-	return output, err
+	return append(dst, '\'')
 }
 
-func (m *Message) escapeArgIfNeeded(a string) string {
-	for _, c := range a {
-		if c < unicode.MaxASCII && (unicode.IsSpace(c) || strings.ContainsRune(`'"\`, c)) {
-			return escapeArgument(a)
+// appendDoubleQuoted appends a to dst in double quotes, backslash-escaping double quotes and backslashes.
+func appendDoubleQuoted(dst []byte, a string) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(a); i++ {
+		if a[i] == '"' || a[i] == '\\' {
+			dst = append(dst, '\\')
 		}
+		dst = append(dst, a[i])
 	}
-	return a
+	return append(dst, '"')
+}
+
+// preferDouble checks whether double-quoting a is shorter than single-quoting it.
+func preferDouble(a string) bool {
+	// Each single quote costs 3 extra bytes in single quotes;
+	// each double quote or backslash costs 1 extra byte in double quotes.
+	extraSingle, extraDouble := 0, 0
+	for i := 0; i < len(a); i++ {
+		switch a[i] {
+		case '\'':
+			extraSingle += 3
+		case '"', '\\':
+			extraDouble++
+		}
+	}
+	return extraDouble < extraSingle
+}
+
+// needsEscape checks whether an argument needs quoting to survive the Tokeniser.
+// This is the case if it is empty, or contains bytes that the Tokeniser treats specially.
+func needsEscape(a string) bool {
+	if a == "" {
+		return true
+	}
+	for i := 0; i < len(a); i++ {
+		switch a[i] {
+		case ' ', '\t', '\n', '\v', '\f', '\r', '\'', '"', '\\':
+			return true
+		}
+	}
+	return false
 }
 
 // Tag returns this Message's tag.
@@ -102,20 +190,45 @@ func (m *Message) Arg(index int) (string, error) {
 	return m.args[index], nil
 }
 
+// Clone returns a deep copy of the given Message.
+// Changes to the copy's arguments don't affect the original, and vice versa.
+func (m *Message) Clone() *Message {
+	c := *m
+	if m.args != nil {
+		c.args = append([]string(nil), m.args...)
+	}
+	return &c
+}
+
+// Equal checks whether two Messages have the same tag, word, and arguments.
+// A Message with no arguments equals another with an empty argument slice.
+func (m *Message) Equal(o *Message) bool {
+	if m.tag != o.tag || m.word != o.word || len(m.args) != len(o.args) {
+		return false
+	}
+	for i, a := range m.args {
+		if a != o.args[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // String returns a string representation of a Message.
 // This isn't necessarily the wire representation: use Pack instead.
+// In particular, String doesn't check that the Message is well-formed.
 func (m *Message) String() string {
-	buf, err := m.packToBuffer()
-	if err != nil {
-		return "(error)"
-	}
-	return buf.String()
+	return string(m.appendPack(nil, QuoteSingle))
 }
 
+// ErrTooFewWords is the error returned by NewFromLine when the line is missing its tag or word.
+var ErrTooFewWords = errors.New("insufficient words")
+
 // NewFromLine constructs a Message struct from a line of word-strings.
+// It fails with ErrTooFewWords if the line doesn't have at least a tag and a word.
 func NewFromLine(line []string) (*Message, error) {
 	if len(line) < 2 {
-		return nil, fmt.Errorf("insufficient words")
+		return nil, ErrTooFewWords
 	}
 
 	msg := New(line[0], line[1]).AddArgs(line[2:]...)
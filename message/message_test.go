@@ -1,9 +1,11 @@
 package message
 
 import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
 	"testing"
 )
-import "reflect"
 
 func TestMessage_WordAndTag(t *testing.T) {
 	cases := []struct {
@@ -128,6 +130,75 @@ func TestPack(t *testing.T) {
 	}
 }
 
-/*
-Helper functions for messages
-*/
+// TestPackWith tests packing messages with each quoting style.
+func TestPackWith(t *testing.T) {
+	msg := New("x", "ITEM").AddArgs("plain", "", "Don't Stop Me Now", `say "hi"`, `C:\dos`)
+
+	cases := []struct {
+		style QuoteStyle
+		want  string
+	}{
+		{QuoteSingle, `x ITEM plain '' 'Don'\''t Stop Me Now' 'say "hi"' 'C:\dos'` + "\n"},
+		{QuoteMinimal, `x ITEM plain '' "Don't Stop Me Now" 'say "hi"' 'C:\dos'` + "\n"},
+		{QuoteDouble, `x ITEM plain "" "Don't Stop Me Now" "say \"hi\"" "C:\\dos"` + "\n"},
+		{QuoteAlways, `x ITEM 'plain' '' 'Don'\''t Stop Me Now' 'say "hi"' 'C:\dos'` + "\n"},
+	}
+
+	for _, c := range cases {
+		got, err := msg.PackWith(c.style)
+		if err != nil {
+			t.Errorf("PackWith(%d) encountered error %q", c.style, err)
+		} else if string(got) != c.want {
+			t.Errorf("PackWith(%d) == %q, want %q", c.style, got, c.want)
+		}
+	}
+}
+
+// TestPackWith_roundTrip checks that the Tokeniser parses the output of each quoting style back into the same words.
+func TestPackWith_roundTrip(t *testing.T) {
+	args := []string{
+		"", " ", "'", `"`, `\`, "''", `\'`, `\"`, "a\nb", "a\r\nb", "\t", "北野 武", "it's a \"test\"", `'"\'"\`,
+	}
+	msg := New("x", "ROUND").AddArgs(args...)
+	want := append([]string{"x", "ROUND"}, args...)
+
+	for _, style := range []QuoteStyle{QuoteSingle, QuoteMinimal, QuoteDouble, QuoteAlways} {
+		packed, err := msg.PackWith(style)
+		if err != nil {
+			t.Fatalf("PackWith(%d) encountered error %q", style, err)
+		}
+
+		r := NewReader(ioutil.NopCloser(bytes.NewReader(packed)))
+		if got, err := r.ReadLine(); err != nil {
+			t.Errorf("ReadLine(%q) gave error %v", packed, err)
+		} else if !cmpWords(got, want) {
+			t.Errorf("ReadLine(PackWith(%d)) == %q, want %q", style, got, want)
+		}
+	}
+}
+
+// TestMessage_CloneEqual checks that Clone makes equal but independent copies of a Message.
+func TestMessage_CloneEqual(t *testing.T) {
+	m := New("a", "b").AddArgs("c", "d")
+	c := m.Clone()
+	if !c.Equal(m) {
+		t.Fatalf("Clone(%q) = %q; want equal", m, c)
+	}
+
+	c.Args()[0] = "x"
+	if m.Args()[0] != "c" {
+		t.Errorf("changing clone's args changed original to %q", m)
+	}
+	if c.Equal(m) {
+		t.Errorf("%q should not equal %q", c, m)
+	}
+
+	if !New("a", "b").Equal(New("a", "b").AddArgs()) {
+		t.Error("message with no args should equal one with empty args")
+	}
+	for _, o := range []*Message{New("x", "b"), New("a", "x"), New("a", "b").AddArgs("c")} {
+		if New("a", "b").Equal(o) {
+			t.Errorf("a b should not equal %q", o)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+package messagetest
+
+// Package messagetest contains helpers for testing code that sends and receives Bifrost messages.
+//
+// It is separate from package message so that production binaries don't import package testing.
@@ -0,0 +1,128 @@
+package messagetest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UniversityRadioYork/bifrost-go/message"
+)
+
+// Diff gets a description of each difference between the messages got and want, field by field.
+// It returns nil if the messages are equal.
+func Diff(got, want *message.Message) []string {
+	var diffs []string
+	if got.Tag() != want.Tag() {
+		diffs = append(diffs, fmt.Sprintf("tag: got %q, want %q", got.Tag(), want.Tag()))
+	}
+	if got.Word() != want.Word() {
+		diffs = append(diffs, fmt.Sprintf("word: got %q, want %q", got.Word(), want.Word()))
+	}
+
+	gargs, wargs := got.Args(), want.Args()
+	for i := 0; i < len(gargs) || i < len(wargs); i++ {
+		switch {
+		case len(gargs) <= i:
+			diffs = append(diffs, fmt.Sprintf("arg %d: missing, want %q", i, wargs[i]))
+		case len(wargs) <= i:
+			diffs = append(diffs, fmt.Sprintf("arg %d: got %q, want nothing", i, gargs[i]))
+		case gargs[i] != wargs[i]:
+			diffs = append(diffs, fmt.Sprintf("arg %d: got %q, want %q", i, gargs[i], wargs[i]))
+		}
+	}
+	return diffs
+}
+
+// AssertMessagesEqual checks whether two messages (got and want) are equal.
+// It throws a test failure listing their differences if not.
+// The parameter in should give a brief description of the context of this assertion.
+func AssertMessagesEqual(t testing.TB, in string, got, want *message.Message) {
+	t.Helper()
+
+	if diffs := Diff(got, want); diffs != nil {
+		t.Errorf("%s: got message %s, want %s\n\t%s", in, brief(got), brief(want), strings.Join(diffs, "\n\t"))
+	}
+}
+
+// AssertReceive receives one message from rx for each message in want, checking that they are equal.
+// It fails the test if any message takes longer than timeout to arrive, or if rx closes early.
+// It returns the messages received.
+//
+// rx is usually the Rx channel of a comm.Endpoint.
+func AssertReceive(t testing.TB, in string, rx <-chan message.Message, timeout time.Duration, want ...*message.Message) []*message.Message {
+	t.Helper()
+
+	got := make([]*message.Message, 0, len(want))
+	for i, w := range want {
+		m, ok := receive(t, fmt.Sprintf("%s: message %d", in, i), rx, timeout)
+		if !ok {
+			break
+		}
+		AssertMessagesEqual(t, fmt.Sprintf("%s: message %d", in, i), m, w)
+		got = append(got, m)
+	}
+	return got
+}
+
+// AssertReceiveMatching receives messages from rx until one matches p, and returns it.
+// It fails the test, and returns nil, if no matching message arrives within timeout, or if rx closes first.
+// Messages that don't match are discarded.
+func AssertReceiveMatching(t testing.TB, in string, rx <-chan message.Message, timeout time.Duration, p *message.Pattern) *message.Message {
+	t.Helper()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case m, ok := <-rx:
+			if !ok {
+				t.Errorf("%s: channel closed while waiting for message matching %s", in, p)
+				return nil
+			}
+			if p.Match(&m) {
+				return &m
+			}
+		case <-deadline.C:
+			t.Errorf("%s: no message matching %s within %s", in, p, timeout)
+			return nil
+		}
+	}
+}
+
+// AssertNoMessage checks that no message arrives on rx within wait.
+// A closed channel counts as no message.
+func AssertNoMessage(t testing.TB, in string, rx <-chan message.Message, wait time.Duration) {
+	t.Helper()
+
+	select {
+	case m, ok := <-rx:
+		if ok {
+			t.Errorf("%s: got unexpected message %s", in, brief(&m))
+		}
+	case <-time.After(wait):
+	}
+}
+
+// receive receives a message from rx, failing the test if it doesn't arrive within timeout or rx closes.
+func receive(t testing.TB, in string, rx <-chan message.Message, timeout time.Duration) (*message.Message, bool) {
+	t.Helper()
+
+	select {
+	case m, ok := <-rx:
+		if !ok {
+			t.Errorf("%s: channel closed", in)
+			return nil, false
+		}
+		return &m, true
+	case <-time.After(timeout):
+		t.Errorf("%s: no message within %s", in, timeout)
+		return nil, false
+	}
+}
+
+// brief gets the string form of m without its trailing newline, for use in failure messages.
+func brief(m *message.Message) string {
+	return strings.TrimSuffix(m.String(), "\n")
+}
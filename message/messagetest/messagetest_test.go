@@ -0,0 +1,105 @@
+package messagetest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UniversityRadioYork/bifrost-go/message"
+)
+
+// ExampleDiff is a testable example for Diff.
+func ExampleDiff() {
+	got := message.New("f00f", "ACK").AddArgs("OK", "success")
+	want := message.New("f00f", "ACK").AddArgs("WHAT", "success", "read")
+	for _, d := range Diff(got, want) {
+		fmt.Println(d)
+	}
+
+	// Output:
+	// arg 0: got "OK", want "WHAT"
+	// arg 2: missing, want "read"
+}
+
+// TestDiff checks Diff against various pairs of messages.
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		got, want *message.Message
+		diffs     []string
+	}{
+		{message.New("a", "b"), message.New("a", "b"), nil},
+		{message.New("a", "b").AddArgs("c"), message.New("a", "b").AddArgs("c"), nil},
+		{message.New("a", "b"), message.New("x", "y"), []string{`tag: got "a", want "x"`, `word: got "b", want "y"`}},
+		{message.New("a", "b").AddArgs("c", "d"), message.New("a", "b"), []string{`arg 0: got "c", want nothing`, `arg 1: got "d", want nothing`}},
+	}
+
+	for _, c := range cases {
+		got := Diff(c.got, c.want)
+		if fmt.Sprint(got) != fmt.Sprint(c.diffs) {
+			t.Errorf("Diff(%q, %q) = %q; want %q", c.got, c.want, got, c.diffs)
+		}
+	}
+}
+
+// recorder is a testing.TB that records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+// Helper does nothing, as a recorder isn't a real test.
+func (r *recorder) Helper() {}
+
+// Errorf records a failure.
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// TestAssertReceive checks AssertReceive on matching, mismatching, and missing messages.
+func TestAssertReceive(t *testing.T) {
+	want := []*message.Message{message.New("a", "b"), message.New("c", "d")}
+
+	rx := make(chan message.Message, 2)
+	rx <- *want[0]
+	rx <- *message.New("c", "x")
+
+	r := &recorder{TB: t}
+	got := AssertReceive(r, "test", rx, 10*time.Millisecond, want...)
+	if len(got) != 2 {
+		t.Errorf("AssertReceive received %d messages; want 2", len(got))
+	}
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], `word: got "x", want "d"`) {
+		t.Errorf("AssertReceive reported %q; want one word mismatch", r.errors)
+	}
+
+	r = &recorder{TB: t}
+	AssertReceive(r, "test", rx, 10*time.Millisecond, want...)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "no message") {
+		t.Errorf("AssertReceive on empty channel reported %q; want a timeout", r.errors)
+	}
+}
+
+// TestAssertReceiveMatching checks that AssertReceiveMatching skips messages until one matches.
+func TestAssertReceiveMatching(t *testing.T) {
+	rx := make(chan message.Message, 3)
+	rx <- *message.New(message.TagBcast, "TIME").AddArgs("1s")
+	rx <- *message.New("f00f", "ACK").AddArgs("OK", "success")
+	close(rx)
+
+	p := message.MustParsePattern("f00f ACK ...")
+	if m := AssertReceiveMatching(t, "test", rx, time.Second, p); m == nil || m.Word() != "ACK" {
+		t.Errorf("AssertReceiveMatching(%s) = %q", p, m)
+	}
+
+	r := &recorder{TB: t}
+	if m := AssertReceiveMatching(r, "test", rx, time.Second, p); m != nil || len(r.errors) != 1 {
+		t.Errorf("AssertReceiveMatching on closed channel = %q, reported %q", m, r.errors)
+	}
+
+	r = &recorder{TB: t}
+	AssertNoMessage(r, "test", make(chan message.Message), time.Millisecond)
+	if len(r.errors) != 0 {
+		t.Errorf("AssertNoMessage reported %q", r.errors)
+	}
+}
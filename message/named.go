@@ -0,0 +1,99 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// File message/named.go contains support for named (key=value) Message arguments.
+//
+// A named argument is an argument of the form key=value, where the key is a non-empty string of ASCII letters,
+// digits, '_', '.', and '-', and the value is any string.
+// Values are quoted like any other argument when packed, so they can contain spaces, quotes, and so on.
+//
+// Named arguments are opt-in per message word: a word that takes them has a fixed number of positional arguments,
+// followed by zero or more named ones.
+// The accessors take that number, so a positional argument that happens to look like key=value is never mistaken
+// for a named one.
+
+// ErrInvalidKey is the error returned when adding a named argument whose key isn't valid; see IsValidKey.
+var ErrInvalidKey = errors.New("invalid named argument key")
+
+// IsValidKey checks whether key can be the key of a named argument.
+func IsValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if !isKeyByte(key[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isKeyByte checks whether b can appear in the key of a named argument.
+func isKeyByte(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9') || b == '_' || b == '.' || b == '-'
+}
+
+// splitNamed splits a into the key and value of a named argument, returning false as its third value if a
+// isn't one.
+func splitNamed(a string) (key, value string, ok bool) {
+	i := strings.IndexByte(a, '=')
+	if i < 0 || !IsValidKey(a[:i]) {
+		return "", "", false
+	}
+	return a[:i], a[i+1:], true
+}
+
+// AddNamed adds a named argument key=value to a Message in-place.
+// The given Message-pointer is returned.
+//
+// AddNamed fails with an error wrapping ErrInvalidKey, leaving m unchanged, if key isn't valid; see IsValidKey.
+func (m *Message) AddNamed(key, value string) (*Message, error) {
+	if !IsValidKey(key) {
+		return m, fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return m.AddArgs(key + "=" + value), nil
+}
+
+// NamedArg gets the value of the named argument key in m, whose first npos arguments are positional.
+// If there is more than one argument with that key, NamedArg gets the first.
+// It returns false as its second value if there is no such argument.
+func (m *Message) NamedArg(npos int, key string) (string, bool) {
+	if npos < 0 || len(m.args) < npos {
+		return "", false
+	}
+	for _, a := range m.args[npos:] {
+		if k, v, ok := splitNamed(a); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// SplitArgs splits m's arguments into its first npos arguments, which are positional, and a map of the named
+// arguments after them.
+// If there is more than one argument with the same key, the map holds the first.
+//
+// SplitArgs fails with an ArityError if m has fewer than npos arguments,
+// and an ArgError if any argument after them isn't named.
+func (m *Message) SplitArgs(npos int) (positional []string, named map[string]string, err error) {
+	if npos < 0 || len(m.args) < npos {
+		return nil, nil, ArityError{Got: len(m.args), Min: npos, Max: -1}
+	}
+
+	named = make(map[string]string)
+	for i, a := range m.args[npos:] {
+		k, v, ok := splitNamed(a)
+		if !ok {
+			return nil, nil, ArgError{Index: npos + i, Value: a, Want: "a named argument"}
+		}
+		if _, dup := named[k]; !dup {
+			named[k] = v
+		}
+	}
+	return append([]string(nil), m.args[:npos]...), named, nil
+}
@@ -0,0 +1,119 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// ExampleMessage_AddNamed is a testable example for AddNamed.
+func ExampleMessage_AddNamed() {
+	m := New(TagBcast, "ITEM").AddArgs("3", "abcdef", "track=1")
+	if _, err := m.AddNamed("title", "Never Gonna Give You Up"); err != nil {
+		fmt.Println(err)
+	}
+	if _, err := m.AddNamed("length", "3m33s"); err != nil {
+		fmt.Println(err)
+	}
+	if _, err := m.AddNamed("bad key", "x"); err != nil {
+		fmt.Println(err)
+	}
+
+	// ITEM has three positional arguments, so the third isn't named, even though it looks like it.
+	fmt.Print(m)
+	fmt.Println(m.NamedArg(3, "title"))
+	fmt.Println(m.NamedArg(3, "track"))
+	fmt.Println(m.SplitArgs(3))
+
+	// Output:
+	// invalid named argument key: "bad key"
+	// ! ITEM 3 abcdef track=1 'title=Never Gonna Give You Up' length=3m33s
+	// Never Gonna Give You Up true
+	//  false
+	// [3 abcdef track=1] map[length:3m33s title:Never Gonna Give You Up] <nil>
+}
+
+// TestMessage_SplitArgs checks splitting various arguments into positional and named ones.
+func TestMessage_SplitArgs(t *testing.T) {
+	m := New("x", "y").AddArgs("pos", "p=q", "a=1", "b.c-d_e=", "a=3", "e==")
+
+	wantPos := []string{"pos", "p=q"}
+	wantNamed := map[string]string{"a": "1", "b.c-d_e": "", "e": "="}
+
+	pos, named, err := m.SplitArgs(2)
+	if err != nil {
+		t.Fatalf("SplitArgs gave error %v", err)
+	}
+	if !cmpWords(pos, wantPos) {
+		t.Errorf("positional args = %q; want %q", pos, wantPos)
+	}
+	if fmt.Sprint(named) != fmt.Sprint(wantNamed) {
+		t.Errorf("named args = %q; want %q", named, wantNamed)
+	}
+}
+
+// TestMessage_SplitArgs_errors checks that SplitArgs rejects missing positional arguments and unnamed trailing ones.
+func TestMessage_SplitArgs_errors(t *testing.T) {
+	cases := []struct {
+		args  []string
+		index int
+	}{
+		{[]string{"pos", "=nokey"}, 1},
+		{[]string{"pos", "a=1", "bad key=2"}, 2},
+		{[]string{"pos", "a=1", "/path"}, 2},
+	}
+
+	for _, c := range cases {
+		var aerr ArgError
+		if _, _, err := New("x", "y").AddArgs(c.args...).SplitArgs(1); !errors.As(err, &aerr) {
+			t.Errorf("SplitArgs(%q) gave error %v; want ArgError", c.args, err)
+		} else if aerr.Index != c.index {
+			t.Errorf("SplitArgs(%q) blamed argument %d; want %d", c.args, aerr.Index, c.index)
+		}
+	}
+
+	var rerr ArityError
+	if _, _, err := New("x", "y").AddArgs("a=1").SplitArgs(2); !errors.As(err, &rerr) {
+		t.Errorf("SplitArgs with too few arguments gave error %v; want ArityError", err)
+	}
+}
+
+// TestMessage_AddNamed_roundTrip checks that named arguments survive packing and unpacking.
+func TestMessage_AddNamed_roundTrip(t *testing.T) {
+	values := []string{"", "plain", "with space", "it's \"quoted\"", "a=b", "new\nline"}
+
+	m := New("x", "y")
+	for i, v := range values {
+		if _, err := m.AddNamed(fmt.Sprintf("k%d", i), v); err != nil {
+			t.Fatalf("AddNamed gave error %v", err)
+		}
+	}
+	packed, err := m.Pack()
+	if err != nil {
+		t.Fatalf("Pack(%q) gave error %v", m, err)
+	}
+	got, err := Unpack(packed)
+	if err != nil {
+		t.Fatalf("Unpack(%q) gave error %v", packed, err)
+	}
+
+	for i, want := range values {
+		key := fmt.Sprintf("k%d", i)
+		if v, ok := got.NamedArg(0, key); !ok || v != want {
+			t.Errorf("NamedArg(%q) = %q, %v; want %q", key, v, ok, want)
+		}
+	}
+}
+
+// TestMessage_AddNamed_invalidKey checks that AddNamed rejects invalid keys without changing the message.
+func TestMessage_AddNamed_invalidKey(t *testing.T) {
+	for _, key := range []string{"", "a b", "a=b", "ключ"} {
+		m := New("x", "y")
+		if _, err := m.AddNamed(key, "v"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("AddNamed(%q, ...) gave error %v; want %v", key, err, ErrInvalidKey)
+		}
+		if len(m.Args()) != 0 {
+			t.Errorf("AddNamed(%q, ...) added arguments %q", key, m.Args())
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+)
+
+// File message/pattern.go contains patterns for matching Messages.
+
+// MatchKind is the enumeration of ways a PartPattern can match part of a Message.
+type MatchKind int
+
+const (
+	// MatchAny matches anything.
+	MatchAny MatchKind = iota
+	// MatchExact matches only its value.
+	MatchExact
+	// MatchPrefix matches anything starting with its value.
+	MatchPrefix
+)
+
+// PartPattern matches a single part (tag, word, or argument) of a Message.
+// The zero PartPattern matches anything.
+type PartPattern struct {
+	// Kind is the kind of match this pattern performs.
+	Kind MatchKind
+	// Value is the value matched against, for MatchExact and MatchPrefix patterns.
+	Value string
+}
+
+// Any is the PartPattern that matches anything.
+var Any = PartPattern{Kind: MatchAny}
+
+// Exact creates a PartPattern that matches only s.
+func Exact(s string) PartPattern {
+	return PartPattern{Kind: MatchExact, Value: s}
+}
+
+// Prefix creates a PartPattern that matches anything starting with s.
+func Prefix(s string) PartPattern {
+	return PartPattern{Kind: MatchPrefix, Value: s}
+}
+
+// Match checks whether s matches the PartPattern.
+func (p PartPattern) Match(s string) bool {
+	switch p.Kind {
+	case MatchExact:
+		return s == p.Value
+	case MatchPrefix:
+		return strings.HasPrefix(s, p.Value)
+	default:
+		return true
+	}
+}
+
+// String gets the compact text form of p; see ParsePattern.
+// The text form isn't quoted.
+func (p PartPattern) String() string {
+	switch p.Kind {
+	case MatchExact:
+		switch {
+		case strings.HasPrefix(p.Value, "="):
+			return "==" + Exact(p.Value[1:]).String()
+		case p.Value == "*" || p.Value == patternRest || strings.HasSuffix(p.Value, "*"):
+			return "=" + p.Value
+		}
+		return p.Value
+	case MatchPrefix:
+		if strings.HasPrefix(p.Value, "=") {
+			return "==" + Prefix(p.Value[1:]).String()
+		}
+		return p.Value + "*"
+	default:
+		return "*"
+	}
+}
+
+// parsePartPattern parses the compact text form of a PartPattern.
+func parsePartPattern(s string) PartPattern {
+	switch {
+	case strings.HasPrefix(s, "=="):
+		// This is an escaped '=' at the start of whatever the rest of s matches.
+		p := parsePartPattern(s[2:])
+		if p.Kind == MatchAny {
+			p.Kind = MatchPrefix
+		}
+		p.Value = "=" + p.Value
+		return p
+	case strings.HasPrefix(s, "="):
+		return Exact(s[1:])
+	case s == "*":
+		return Any
+	case strings.HasSuffix(s, "*"):
+		return Prefix(s[:len(s)-1])
+	default:
+		return Exact(s)
+	}
+}
+
+// Pattern matches Messages by their tag, word, and arguments.
+type Pattern struct {
+	// Tag matches the Message's tag.
+	Tag PartPattern
+	// Word matches the Message's word.
+	Word PartPattern
+	// Args matches the Message's arguments, one by one.
+	Args []PartPattern
+	// Rest, if true, lets the Pattern match Messages with any number of arguments beyond those in Args.
+	Rest bool
+}
+
+// patternRest is the compact text form of a Pattern's Rest flag.
+const patternRest = "..."
+
+// ParsePattern parses a Pattern from its compact text form.
+//
+// The text form is a Bifrost line (without the newline): a tag pattern, a word pattern, and zero or more argument
+// patterns, quoted as in a Bifrost message.
+// Each pattern is either '*' (matching anything), a string ending in '*' (matching anything with that prefix),
+// or any other string (matching exactly that string).
+// A pattern starting with '=' matches the rest of the pattern exactly, so '=*' matches only '*';
+// one starting with '==' matches '=' followed by whatever the rest of the pattern matches, so '==x*' matches anything
+// starting with '=x'.
+// The last pattern may be '...', which matches any number of further arguments.
+//
+// For example, "! ITEM 3* ..." matches every broadcast ITEM whose first argument starts with 3.
+func ParsePattern(s string) (*Pattern, error) {
+	words, err := unpackWords([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("bad pattern %q: %w", s, err)
+	}
+	if len(words) < 2 {
+		return nil, fmt.Errorf("bad pattern %q: need at least a tag and a word", s)
+	}
+
+	p := Pattern{Tag: parsePartPattern(words[0]), Word: parsePartPattern(words[1])}
+
+	args := words[2:]
+	if n := len(args); n > 0 && args[n-1] == patternRest {
+		p.Rest = true
+		args = args[:n-1]
+	}
+	for _, a := range args {
+		if a == patternRest {
+			return nil, fmt.Errorf("bad pattern %q: %s must come last", s, patternRest)
+		}
+		p.Args = append(p.Args, parsePartPattern(a))
+	}
+
+	return &p, nil
+}
+
+// MustParsePattern is like ParsePattern, but panics if the pattern is invalid.
+// It is intended for patterns in variable initialisers and tests.
+func MustParsePattern(s string) *Pattern {
+	p, err := ParsePattern(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Match checks whether m matches the Pattern.
+func (p *Pattern) Match(m *Message) bool {
+	if !p.Tag.Match(m.tag) || !p.Word.Match(m.word) {
+		return false
+	}
+	if len(m.args) < len(p.Args) || (!p.Rest && len(p.Args) < len(m.args)) {
+		return false
+	}
+	for i, ap := range p.Args {
+		if !ap.Match(m.args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// String gets the compact text form of p, such that ParsePattern(p.String()) matches the same messages as p.
+func (p *Pattern) String() string {
+	var b []byte
+	b = appendArg(b, p.Tag.String(), QuoteMinimal)
+	b = append(b, ' ')
+	b = appendArg(b, p.Word.String(), QuoteMinimal)
+	for _, a := range p.Args {
+		b = append(b, ' ')
+		b = appendArg(b, a.String(), QuoteMinimal)
+	}
+	if p.Rest {
+		b = append(b, " "+patternRest...)
+	}
+	return string(b)
+}
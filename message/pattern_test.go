@@ -0,0 +1,105 @@
+package message
+
+import (
+	"fmt"
+	"testing"
+)
+
+// ExampleParsePattern is a testable example for ParsePattern.
+func ExampleParsePattern() {
+	p, err := ParsePattern("! ITEM 3* ...")
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+
+	fmt.Println(p.Match(New(TagBcast, "ITEM").AddArgs("3", "abcdef", "track")))
+	fmt.Println(p.Match(New(TagBcast, "ITEM").AddArgs("30")))
+	fmt.Println(p.Match(New(TagBcast, "ITEM").AddArgs("4", "abcdef")))
+	fmt.Println(p.Match(New("f00f", "ITEM").AddArgs("3")))
+
+	// Output:
+	// true
+	// true
+	// false
+	// false
+}
+
+// TestPattern_Match tests matching various patterns against messages.
+func TestPattern_Match(t *testing.T) {
+	cases := []struct {
+		pattern string
+		msg     *Message
+		want    bool
+	}{
+		{"* *", New("x", "y"), true},
+		{"* *", New("x", "y").AddArgs("z"), false},
+		{"* * ...", New("x", "y").AddArgs("z"), true},
+		{"* * ...", New("x", "y"), true},
+		{"! *", New("?", "y"), false},
+		{"* ACK OK", New("f00f", "ACK").AddArgs("OK"), true},
+		{"* ACK OK", New("f00f", "ACK").AddArgs("FAIL"), false},
+		{"* ACK * ...", New("f00f", "ACK"), false},
+		{"* * /player/*", New("x", "read").AddArgs("/player/time"), true},
+		{"* * /player/*", New("x", "read").AddArgs("/control/state"), false},
+		{"* * =*", New("x", "y").AddArgs("*"), true},
+		{"* * =*", New("x", "y").AddArgs("foo"), false},
+		{"* * =...", New("x", "y").AddArgs("..."), true},
+		{"* * ==x*", New("x", "y").AddArgs("=xy"), true},
+		{"* * ==x*", New("x", "y").AddArgs("=x*"), true},
+		{"* * ==x*", New("x", "y").AddArgs("xy"), false},
+		{"* * ===x*", New("x", "y").AddArgs("=x*"), true},
+		{"* * ===x*", New("x", "y").AddArgs("=xy"), false},
+		{"* * 'a b*'", New("x", "y").AddArgs("a bc"), true},
+		{"* * ''", New("x", "y").AddArgs(""), true},
+		{"* * ''", New("x", "y").AddArgs("a"), false},
+	}
+
+	for _, c := range cases {
+		p, err := ParsePattern(c.pattern)
+		if err != nil {
+			t.Errorf("ParsePattern(%q) gave error %v", c.pattern, err)
+			continue
+		}
+		if got := p.Match(c.msg); got != c.want {
+			t.Errorf("ParsePattern(%q).Match(%q) = %v; want %v", c.pattern, c.msg, got, c.want)
+		}
+	}
+}
+
+// TestPattern_String_roundTrip checks that parsing a Pattern's String gives back the same Pattern.
+func TestPattern_String_roundTrip(t *testing.T) {
+	cases := []*Pattern{
+		{Tag: Any, Word: Any},
+		{Tag: Exact(TagBcast), Word: Exact("ITEM"), Args: []PartPattern{Prefix("3"), Any}, Rest: true},
+		{Tag: Exact("=x"), Word: Exact("*"), Args: []PartPattern{Exact("..."), Exact("a*"), Exact(""), Prefix("a b")}},
+		{Tag: Prefix("=x"), Word: Exact("=x*"), Args: []PartPattern{Prefix("="), Exact("="), Exact("=..."), Prefix("==*")}},
+	}
+
+	for _, want := range cases {
+		s := want.String()
+		got, err := ParsePattern(s)
+		if err != nil {
+			t.Errorf("ParsePattern(%q) gave error %v", s, err)
+		} else if got.String() != s || fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("ParsePattern(%q) = %v; want %v", s, got, want)
+		}
+	}
+}
+
+// TestParsePattern_errors checks that ParsePattern rejects bad patterns.
+func TestParsePattern_errors(t *testing.T) {
+	cases := []string{
+		"",
+		"!",
+		"! ITEM 'unclosed",
+		"! ITEM ... 3",
+		"! ITEM\n3",
+	}
+
+	for _, c := range cases {
+		if p, err := ParsePattern(c); err == nil {
+			t.Errorf("ParsePattern(%q) = %v; want error", c, p)
+		}
+	}
+}
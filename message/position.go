@@ -0,0 +1,119 @@
+package message
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// File message/position.go contains position tracking for Tokeniser and Reader diagnostics.
+
+// maxSnippet is the maximum number of bytes of offending input kept in a SyntaxError.
+const maxSnippet = 64
+
+// Position is the position of a byte in a Tokeniser's input.
+type Position struct {
+	// Line is the 1-based line number of the byte.
+	// Newlines inside quotes count as line breaks, so this is the line a text editor would show.
+	Line int
+	// Column is the 1-based column of the byte, counted in UTF-8 characters.
+	// Bytes that aren't valid UTF-8 count as one character each.
+	Column int
+	// Offset is the 0-based byte offset of the byte from the start of the input.
+	Offset int
+}
+
+// String gets a human-readable description of a Position.
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d (byte %d)", p.Line, p.Column, p.Offset)
+}
+
+// SyntaxError is the error returned when a Tokeniser or Reader finds a problem in its input.
+// It wraps the underlying error (such as a LimitError) with the position of the problem.
+type SyntaxError struct {
+	// Position is the position at which the problem was found.
+	Position
+
+	// Snippet holds the start of the offending line, up to and including the problem.
+	// It is cut short if the line is very long.
+	Snippet string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error protocol for SyntaxError.
+func (s SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %v (in %q)", s.Position, s.Err, s.Snippet)
+}
+
+// Unwrap gets the error underlying a SyntaxError.
+func (s SyntaxError) Unwrap() error {
+	return s.Err
+}
+
+// snippetOf gets the first line of b, cut short if it is very long, as a SyntaxError snippet.
+func snippetOf(b []byte) string {
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	if len(b) > maxSnippet {
+		b = b[:maxSnippet]
+	}
+	return strings.TrimRight(string(b), "\r")
+}
+
+// advance moves the Tokeniser's position past the raw input byte b, returning the position of b.
+func (t *Tokeniser) advance(b byte) Position {
+	if !t.inLine {
+		t.inLine = true
+		t.lineStart = t.pos
+		t.snippet = t.snippet[:0]
+	}
+	if len(t.snippet) < maxSnippet {
+		t.snippet = append(t.snippet, b)
+	}
+
+	here := t.pos
+	t.pos.Offset++
+	switch {
+	case b == '\n':
+		t.pos.Line++
+		t.pos.Column = 1
+		t.cont = 0
+	case utf8.RuneStart(b):
+		t.pos.Column++
+		t.cont = utf8ContinuationBytes(b)
+	case 0 < t.cont:
+		// Continuation bytes belong to the character they continue.
+		t.cont--
+		here.Column--
+	default:
+		// A stray continuation byte isn't valid UTF-8, so it counts as a character of its own.
+		t.pos.Column++
+	}
+	return here
+}
+
+// utf8ContinuationBytes gets the number of continuation bytes that follow the UTF-8 start byte b.
+// Start bytes that can't begin a valid character have none.
+func utf8ContinuationBytes(b byte) int {
+	switch {
+	case 0xF5 <= b:
+		return 0
+	case 0xF0 <= b:
+		return 3
+	case 0xE0 <= b:
+		return 2
+	case 0xC2 <= b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// syntaxError wraps err in a SyntaxError at pos, using the current line as the snippet.
+func (t *Tokeniser) syntaxError(pos Position, err error) SyntaxError {
+	return SyntaxError{Position: pos, Snippet: strings.TrimRight(string(t.snippet), "\r\n"), Err: err}
+}
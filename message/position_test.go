@@ -0,0 +1,76 @@
+package message
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// ExampleReader_ReadMessage is a testable example for ReadMessage.
+func ExampleReader_ReadMessage() {
+	in := "f00f read /player/time\nbroken\n"
+	r := NewReader(ioutil.NopCloser(strings.NewReader(in)))
+
+	for {
+		m, err := r.ReadMessage()
+		if err != nil {
+			fmt.Println("error:", err)
+			break
+		}
+		fmt.Print(m)
+	}
+
+	// Output:
+	// f00f read /player/time
+	// error: line 2, column 1 (byte 23): insufficient words (in "broken")
+}
+
+// TestReader_syntaxErrors checks the positions and snippets of the Reader's SyntaxErrors.
+func TestReader_syntaxErrors(t *testing.T) {
+	cases := []struct {
+		in      string
+		limits  Limits
+		want    Position
+		snippet string
+		err     error
+	}{
+		// The word count is broken by the end of the third word.
+		{"a b\nc d e\n", Limits{MaxWords: 2}, Position{Line: 2, Column: 6, Offset: 9}, "c d e", LimitError{Limit: LimitWordCount, Max: 2}},
+		// Columns count characters, not bytes.
+		{"北野 武 abcdefg\n", Limits{MaxWordLength: 6}, Position{Line: 1, Column: 12, Offset: 17}, "北野 武 abcdefg", LimitError{Limit: LimitWordLength, Max: 6}},
+		// Stray continuation bytes count as characters of their own.
+		{"\x80\x80 b c\n", Limits{MaxWords: 2}, Position{Line: 1, Column: 7, Offset: 6}, "\x80\x80 b c", LimitError{Limit: LimitWordCount, Max: 2}},
+		{"é\x80 b c\n", Limits{MaxWords: 2}, Position{Line: 1, Column: 7, Offset: 7}, "é\x80 b c", LimitError{Limit: LimitWordCount, Max: 2}},
+		// Newlines in quotes start new lines for position purposes.
+		{"x y 'z\nw", Limits{}, Position{Line: 2, Column: 2, Offset: 8}, "x y 'z\nw", TruncatedError{Pending: PendingSingleQuote}},
+		{"a b c\n\n", Limits{}, Position{Line: 2, Column: 1, Offset: 6}, "", ErrTooFewWords},
+	}
+
+	for _, c := range cases {
+		r := NewReader(ioutil.NopCloser(bytes.NewReader([]byte(c.in))))
+		r.SetLimits(c.limits)
+
+		var err error
+		for err == nil {
+			_, err = r.ReadMessage()
+		}
+
+		var serr SyntaxError
+		if !errors.As(err, &serr) {
+			t.Errorf("ReadMessage(%q) gave error %v; want SyntaxError", c.in, err)
+			continue
+		}
+		if serr.Position != c.want {
+			t.Errorf("ReadMessage(%q) error at %v; want %v", c.in, serr.Position, c.want)
+		}
+		if serr.Snippet != c.snippet {
+			t.Errorf("ReadMessage(%q) error snippet %q; want %q", c.in, serr.Snippet, c.snippet)
+		}
+		if serr.Err != c.err {
+			t.Errorf("ReadMessage(%q) error wraps %v; want %v", c.in, serr.Err, c.err)
+		}
+	}
+}
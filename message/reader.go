@@ -1,6 +1,27 @@
 package message
 
-import "io"
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// TruncatedError is the error returned by ReadLine when the input ends part-way through a line.
+// It wraps io.ErrUnexpectedEOF.
+type TruncatedError struct {
+	// Pending describes what was left open when the input ended.
+	Pending Pending
+}
+
+// Error implements the error protocol for TruncatedError.
+func (t TruncatedError) Error() string {
+	return fmt.Sprintf("input truncated: %s", t.Pending)
+}
+
+// Unwrap gets the error underlying a TruncatedError, which is always io.ErrUnexpectedEOF.
+func (t TruncatedError) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
 
 // Reader wraps a ReadCloser to provide message-level reading functionality.
 type Reader struct {
@@ -9,6 +30,8 @@ type Reader struct {
 	buf    [4096]byte
 	pos    int
 	max    int
+	// rerr holds any error from the last read, to be returned once the bytes from that read are tokenised.
+	rerr error
 }
 
 // Close closes the Reader's underlying ReadCloser.
@@ -16,18 +39,45 @@ func (r *Reader) Close() error {
 	return r.reader.Close()
 }
 
-// tokeniseUntilLine drains t's internal buffer into its tokeniser until it runs out or produces a line.
-func (r *Reader) tokeniseUntilLine() (line []string, lineok bool) {
+// SetMode sets the InputMode the Reader uses to pre-process its input.
+// Use ModeInteractive for connections from telnet or netcat.
+func (r *Reader) SetMode(m InputMode) {
+	r.tok.SetMode(m)
+}
+
+// SetRecovery sets the Reader's policy for recovering from damaged lines, such as those with unclosed quotes.
+// Damaged lines cause ReadLine to return a SyntaxError wrapping a DamagedLineError;
+// the Reader then carries on from the next line.
+func (r *Reader) SetRecovery(rc Recovery) {
+	r.tok.SetRecovery(rc)
+}
+
+// SetTextPolicy sets the policy the Reader applies to the words of each line it reads.
+// Lines the policy rejects cause ReadLine to return a SyntaxError wrapping an EncodingError;
+// the Reader then carries on from the next line.
+func (r *Reader) SetTextPolicy(p TextPolicy) {
+	r.tok.SetTextPolicy(p)
+}
+
+// SetLimits sets the resource limits enforced on lines read by the Reader.
+// Lines breaking these limits cause ReadLine to return a SyntaxError wrapping a LimitError;
+// the Reader then skips to the next line.
+func (r *Reader) SetLimits(l Limits) {
+	r.tok.SetLimits(l)
+}
+
+// tokeniseUntilLine drains t's internal buffer into its tokeniser until it runs out, produces a line, or errors.
+func (r *Reader) tokeniseUntilLine() (lineok bool, err error) {
 	var nread int
-	for r.pos < r.max && !lineok {
-		nread, lineok, line = r.tok.TokeniseBytes(r.buf[r.pos:r.max])
+	for r.pos < r.max && !lineok && err == nil {
+		nread, lineok, err = r.tok.tokenise(r.buf[r.pos:r.max])
 		r.pos += nread
 	}
 	return
 }
 
 // fillFromReader fills t's internal buffer using its reader.
-// It can fail with errors from the reader.
+// It can fail with errors from the reader; the buffer may still contain bytes read before the error.
 func (r *Reader) fillFromReader() (err error) {
 	r.pos = 0
 	r.max, err = r.reader.Read(r.buf[:])
@@ -36,15 +86,75 @@ func (r *Reader) fillFromReader() (err error) {
 
 // ReadLine reads a tokenised line from the Reader.
 // ReadLine may return an error if the Reader chokes.
+// Problems with the input itself are SyntaxErrors, which give the position of the problem.
+// If the error wraps a LimitError, DamagedLineError, or EncodingError, the offending line has been skipped,
+// and it is safe to call ReadLine again.
+//
+// At the end of the input, ReadLine returns io.EOF if the input ended cleanly between lines,
+// and a SyntaxError wrapping a TruncatedError if it ended part-way through one.
 func (r *Reader) ReadLine() ([]string, error) {
+	if err := r.readLine(); err != nil {
+		return []string{}, err
+	}
+	return r.tok.lineStrings(), nil
+}
+
+// ReadLineView is like ReadLine, but returns the line as views into the Reader's internal buffer.
+// This avoids allocating, but the views are only valid until the next call to the Reader.
+func (r *Reader) ReadLineView() ([][]byte, error) {
+	if err := r.readLine(); err != nil {
+		return nil, err
+	}
+	return r.tok.lineViews(), nil
+}
+
+// ReadMessage reads a line from the Reader, then converts it to a Message.
+// It fails with a SyntaxError if the line can't be read, or doesn't make a valid Message.
+func (r *Reader) ReadMessage() (*Message, error) {
+	line, err := r.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := NewFromLine(line)
+	if err != nil {
+		return nil, r.tok.syntaxError(r.tok.lineStart, err)
+	}
+	return m, nil
+}
+
+// LineStart gets the position at which the line most recently read by the Reader started.
+func (r *Reader) LineStart() Position {
+	return r.tok.LineStart()
+}
+
+// readLine reads until the Reader's tokeniser holds a complete line.
+func (r *Reader) readLine() error {
 	for {
-		if line, lineok := r.tokeniseUntilLine(); lineok {
-			return line, nil
+		lineok, err := r.tokeniseUntilLine()
+		if err != nil {
+			return err
+		}
+		if lineok {
+			return nil
 		}
-		if err := r.fillFromReader(); err != nil {
-			return []string{}, err
+		if r.rerr != nil {
+			err, r.rerr = r.rerr, nil
+			return r.eofError(err)
 		}
+		r.rerr = r.fillFromReader()
+	}
+}
+
+// eofError converts err to a SyntaxError wrapping a TruncatedError if it is an EOF that happened part-way through a line.
+func (r *Reader) eofError(err error) error {
+	if !errors.Is(err, io.EOF) {
+		return err
+	}
+	if p := r.tok.Pending(); p != PendingNone {
+		return r.tok.syntaxError(r.tok.pos, TruncatedError{Pending: p})
 	}
+	return err
 }
 
 // NewReader creates and returns a new, empty Reader.
@@ -0,0 +1,83 @@
+package message
+
+import (
+	"fmt"
+)
+
+// File message/recovery.go contains the Tokeniser's policy for recovering from damaged lines.
+
+// Recovery holds a Tokeniser's policy for recovering from damaged input, such as a stray unbalanced quote.
+// Without recovery, a single unclosed quote makes the Tokeniser swallow the rest of the input.
+// The zero Recovery disables recovery.
+type Recovery struct {
+	// LimitQuotedNewlines enables the MaxQuotedNewlines limit.
+	LimitQuotedNewlines bool
+
+	// MaxQuotedNewlines, if LimitQuotedNewlines is set, is the number of newlines a line can contain inside quotes.
+	// The next quoted newline ends the line, which is reported as damaged.
+	// A limit of 0 is the strictest policy: any newline inside quotes ends the line, so the next line is never lost.
+	MaxQuotedNewlines int
+
+	// Sentinel, if non-zero, is a byte that abandons the current line wherever it appears, even inside quotes.
+	// Any partial line is reported as damaged.
+	// A good choice for interactive sessions is 0x03, which telnet and netcat send on Ctrl-C in some modes.
+	Sentinel byte
+}
+
+// DamagedLineError is the error returned when a Tokeniser abandons a line under its Recovery policy.
+// The Tokeniser carries on tokenising from the next line.
+type DamagedLineError struct {
+	// Pending describes what was left open when the line was abandoned.
+	Pending Pending
+	// Sentinel is true if the line was abandoned because of the Recovery sentinel,
+	// and false if it had too many quoted newlines.
+	Sentinel bool
+}
+
+// Error implements the error protocol for DamagedLineError.
+func (d DamagedLineError) Error() string {
+	if d.Sentinel {
+		return fmt.Sprintf("line abandoned at sentinel (%s)", d.Pending)
+	}
+	return fmt.Sprintf("line abandoned after too many quoted newlines (%s)", d.Pending)
+}
+
+// SetRecovery sets the Tokeniser's policy for recovering from damaged lines.
+// The new policy takes effect from the next byte tokenised.
+func (t *Tokeniser) SetRecovery(r Recovery) {
+	t.recovery = r
+}
+
+// recover checks b against the Tokeniser's Recovery policy, abandoning the current line if needed.
+// It returns true if b was consumed by abandoning the line.
+func (t *Tokeniser) recover(b byte) bool {
+	if t.recovery.Sentinel != 0 && b == t.recovery.Sentinel {
+		t.abandon(true)
+		return true
+	}
+
+	if b != '\n' || !(t.state == stateSingle || t.state == stateDouble || t.state == stateDoubleEscape) {
+		return false
+	}
+	t.quotedNewlines++
+	if !t.recovery.LimitQuotedNewlines || t.quotedNewlines <= t.recovery.MaxQuotedNewlines {
+		return false
+	}
+	t.abandon(false)
+	return true
+}
+
+// abandon throws away the current line, reporting it as damaged unless there was nothing to throw away,
+// or the line already broke a limit.
+func (t *Tokeniser) abandon(sentinel bool) {
+	if p := t.Pending(); p != PendingNone && !t.discarding {
+		t.err = DamagedLineError{Pending: p, Sentinel: sentinel}
+	}
+
+	t.state = stateNone
+	t.discarding = false
+	t.lineLen = 0
+	t.quotedNewlines = 0
+	t.resetLine()
+	t.inLine = false
+}
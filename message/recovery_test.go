@@ -0,0 +1,102 @@
+package message
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// ExampleReader_SetRecovery is a testable example for SetRecovery.
+func ExampleReader_SetRecovery() {
+	in := "x write /a 'oops\nx read /a\nx read /b\n"
+	r := NewReader(ioutil.NopCloser(strings.NewReader(in)))
+	r.SetRecovery(Recovery{LimitQuotedNewlines: true, MaxQuotedNewlines: 0})
+
+	for {
+		line, err := r.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Println("error:", err)
+			continue
+		}
+		fmt.Println(line)
+	}
+
+	// Output:
+	// error: line 1, column 17 (byte 16): line abandoned after too many quoted newlines (unclosed single quote) (in "x write /a 'oops")
+	// [x read /a]
+	// [x read /b]
+}
+
+// TestReader_SetRecovery checks that a Reader recovers from damaged lines under various policies.
+func TestReader_SetRecovery(t *testing.T) {
+	cases := []struct {
+		recovery Recovery
+		in       string
+		// want holds the lines read; nil entries are damaged lines.
+		want [][]string
+	}{
+		// With a limit of 0, the first quoted newline ends the line, and the next line survives.
+		{Recovery{LimitQuotedNewlines: true}, "a 'b\nc d\n", [][]string{nil, {"c", "d"}}},
+		{Recovery{LimitQuotedNewlines: true}, "a \"b\\\nc d\n", [][]string{nil, {"c", "d"}}},
+		// Quoted newlines within the limit are fine.
+		{Recovery{LimitQuotedNewlines: true, MaxQuotedNewlines: 1}, "a 'b\nc'\n", [][]string{{"a", "b\nc"}}},
+		{Recovery{LimitQuotedNewlines: true, MaxQuotedNewlines: 1}, "a 'b\n\nc\nd e\n", [][]string{nil, {"c"}, {"d", "e"}}},
+		{Recovery{LimitQuotedNewlines: true, MaxQuotedNewlines: 2}, "a \"b\n\n\nc d\n", [][]string{nil, {"c", "d"}}},
+		// Escaped newlines outside quotes don't count.
+		{Recovery{LimitQuotedNewlines: true, MaxQuotedNewlines: 1}, "a\\\nb\\\nc\n", [][]string{{"a\nb\nc"}}},
+		{Recovery{Sentinel: 3}, "a 'b\x03c d\n", [][]string{nil, {"c", "d"}}},
+		{Recovery{Sentinel: 3}, "a \"b\\\x03c d\n", [][]string{nil, {"c", "d"}}},
+		// A sentinel between lines does nothing.
+		{Recovery{Sentinel: 3}, "a b\n\x03c d\n", [][]string{{"a", "b"}, {"c", "d"}}},
+		// Without recovery, the quote swallows everything.
+		{Recovery{}, "a 'b\n\n\x03c\nd e\n'\n", [][]string{{"a", "b\n\n\x03c\nd e\n"}}},
+	}
+
+	for _, c := range cases {
+		r := NewReader(ioutil.NopCloser(bytes.NewReader([]byte(c.in))))
+		r.SetRecovery(c.recovery)
+
+		var got [][]string
+		for {
+			line, err := r.ReadLine()
+			if err == io.EOF {
+				break
+			}
+
+			var derr DamagedLineError
+			if errors.As(err, &derr) {
+				got = append(got, nil)
+				continue
+			}
+			if err != nil {
+				t.Errorf("ReadLine(%q) with %v gave error %v", c.in, c.recovery, err)
+				break
+			}
+			got = append(got, line)
+		}
+
+		if !cmpDamagedLines(got, c.want) {
+			t.Errorf("ReadLine(%q) with %v == %q; want %q", c.in, c.recovery, got, c.want)
+		}
+	}
+}
+
+// cmpDamagedLines is like cmpLines, but distinguishes nil (damaged) lines from empty ones.
+func cmpDamagedLines(a, b [][]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if (a[i] == nil) != (b[i] == nil) || !cmpWords(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
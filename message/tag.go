@@ -1,6 +1,13 @@
 package message
 
-import "github.com/google/uuid"
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
 
 const (
 	// TagBcast is the tag used for broadcasts.
@@ -10,14 +17,84 @@ const (
 	TagUnknown string = "?"
 )
 
-// NewTag generates a pseudorandom tag.
+// TagSource is the interface of things that generate tags.
+type TagSource interface {
+	// NewTag generates a tag.
+	// This tag should be unique enough to distinguish any communications sent using it from others
+	// made using tags from the same source.
+	NewTag() (string, error)
+}
+
+// DefaultTagSource is the TagSource used by NewTag.
+var DefaultTagSource TagSource = UUIDTagSource{}
+
+// NewTag generates a pseudorandom tag using DefaultTagSource.
 // This tag should be unique enough to distinguish any communications sent using it from others,
 // including those made by the same client or server.
 func NewTag() (string, error) {
-	// this should be Version 1
-	u, err := uuid.NewUUID()
+	return DefaultTagSource.NewTag()
+}
+
+// UUIDTagSource is a TagSource that generates random (version 4) UUIDs.
+// Unlike time-based UUIDs, these don't leak the host's MAC address or clock.
+type UUIDTagSource struct{}
+
+// NewTag generates a random UUID tag.
+func (UUIDTagSource) NewTag() (string, error) {
+	u, err := uuid.NewRandom()
 	if err != nil {
 		return "", err
 	}
 	return u.String(), nil
 }
+
+// CounterTagSource is a TagSource that generates tags from a counter, optionally with a prefix.
+// Counter tags are compact and deterministic, which makes them useful for single connections and tests.
+// A CounterTagSource is safe for concurrent use.
+type CounterTagSource struct {
+	// Prefix is prepended to each tag.
+	Prefix string
+
+	// n is the last counter value used.
+	n uint64
+}
+
+// NewCounterTagSource creates a CounterTagSource whose tags start with prefix.
+// The first tag it generates has counter value 1.
+func NewCounterTagSource(prefix string) *CounterTagSource {
+	return &CounterTagSource{Prefix: prefix}
+}
+
+// NewTag generates the next counter tag.
+func (c *CounterTagSource) NewTag() (string, error) {
+	return c.Prefix + strconv.FormatUint(atomic.AddUint64(&c.n, 1), 10), nil
+}
+
+// shortTagEncoding is the base-32 encoding used for short tags.
+var shortTagEncoding = base32.NewEncoding("0123456789abcdefghjkmnpqrstvwxyz").WithPadding(base32.NoPadding)
+
+// DefaultShortTagLength is the length of tags generated by a ShortTagSource with no Length set.
+// Eight base-32 characters give 40 bits of randomness.
+const DefaultShortTagLength = 8
+
+// ShortTagSource is a TagSource that generates short random tags in lowercase base 32.
+type ShortTagSource struct {
+	// Length is the number of characters in each tag.
+	// If zero, DefaultShortTagLength is used.
+	Length int
+}
+
+// NewTag generates a short random tag.
+func (s ShortTagSource) NewTag() (string, error) {
+	n := s.Length
+	if n <= 0 {
+		n = DefaultShortTagLength
+	}
+
+	// Each byte gives 8/5 characters; round up, then trim.
+	raw := make([]byte, (n*5+7)/8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return shortTagEncoding.EncodeToString(raw)[:n], nil
+}
@@ -0,0 +1,53 @@
+package message
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+// ExampleCounterTagSource is a testable example for CounterTagSource.
+func ExampleCounterTagSource() {
+	ts := NewCounterTagSource("c")
+	for i := 0; i < 3; i++ {
+		tag, _ := ts.NewTag()
+		fmt.Println(tag)
+	}
+
+	// Output:
+	// c1
+	// c2
+	// c3
+}
+
+// TestTagSources checks that each TagSource generates distinct tags of the right shape.
+func TestTagSources(t *testing.T) {
+	cases := []struct {
+		name string
+		ts   TagSource
+		re   *regexp.Regexp
+	}{
+		{"uuid", UUIDTagSource{}, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{"counter", NewCounterTagSource("x"), regexp.MustCompile(`^x[0-9]+$`)},
+		{"short", ShortTagSource{}, regexp.MustCompile(`^[0-9a-z]{8}$`)},
+		{"short-5", ShortTagSource{Length: 5}, regexp.MustCompile(`^[0-9a-z]{5}$`)},
+		{"default", DefaultTagSource, regexp.MustCompile(`.`)},
+	}
+
+	for _, c := range cases {
+		seen := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			tag, err := c.ts.NewTag()
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", c.name, err)
+			}
+			if !c.re.MatchString(tag) {
+				t.Errorf("%s: tag %q doesn't match %s", c.name, tag, c.re)
+			}
+			if seen[tag] {
+				t.Errorf("%s: tag %q repeated", c.name, tag)
+			}
+			seen[tag] = true
+		}
+	}
+}
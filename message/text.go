@@ -0,0 +1,130 @@
+package message
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// File message/text.go contains policies for checking and normalising the text in Messages.
+
+// UTF8Policy is the enumeration of ways to handle words that aren't valid UTF-8.
+type UTF8Policy int
+
+const (
+	// UTF8Allow passes invalid UTF-8 through unchanged.
+	UTF8Allow UTF8Policy = iota
+	// UTF8Reject rejects invalid UTF-8 with an EncodingError.
+	UTF8Reject
+	// UTF8Replace replaces each run of invalid UTF-8 bytes with U+FFFD, the Unicode replacement character.
+	UTF8Replace
+)
+
+// TextPolicy holds a policy for checking and normalising the text of message words.
+// The zero TextPolicy leaves text alone.
+type TextPolicy struct {
+	// Invalid is the policy for words that aren't valid UTF-8.
+	Invalid UTF8Policy
+
+	// NFC, if true, converts words to Unicode Normalization Form C.
+	// This makes strings that look the same compare equal, whichever way the sender composed their characters.
+	NFC bool
+}
+
+// EncodingError is the error returned when a TextPolicy rejects a word that isn't valid UTF-8.
+type EncodingError struct {
+	// Word is the index of the offending word in its line: 0 is the tag, 1 the message word, and 2 onwards the
+	// arguments.
+	Word int
+	// Value is the offending word.
+	Value string
+}
+
+// Error implements the error protocol for EncodingError.
+func (e EncodingError) Error() string {
+	return fmt.Sprintf("word %d (%q) is not valid UTF-8", e.Word, e.Value)
+}
+
+// fixString applies p to s, returning false as its second value if p rejects s.
+func (p TextPolicy) fixString(s string) (string, bool) {
+	if p.Invalid != UTF8Allow && !utf8.ValidString(s) {
+		if p.Invalid == UTF8Reject {
+			return "", false
+		}
+		s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	}
+	if p.NFC {
+		s = norm.NFC.String(s)
+	}
+	return s, true
+}
+
+// fixBytes applies p to w, returning the fixed word, whether it differs from w,
+// and false as its third value if p rejects w.
+func (p TextPolicy) fixBytes(w []byte) (fixed []byte, changed, ok bool) {
+	if p.Invalid != UTF8Allow && !utf8.Valid(w) {
+		if p.Invalid == UTF8Reject {
+			return nil, false, false
+		}
+		w = bytes.ToValidUTF8(w, []byte(string(utf8.RuneError)))
+		changed = true
+	}
+	if p.NFC && !norm.NFC.IsNormal(w) {
+		w = norm.NFC.Bytes(w)
+		changed = true
+	}
+	return w, changed, true
+}
+
+// ApplyTextPolicy applies p to the tag, word, and arguments of a Message in-place.
+// If p rejects any of them, ApplyTextPolicy returns an EncodingError and leaves the Message unchanged.
+func (m *Message) ApplyTextPolicy(p TextPolicy) error {
+	words := make([]string, 0, 2+len(m.args))
+	for i, s := range append([]string{m.tag, m.word}, m.args...) {
+		fixed, ok := p.fixString(s)
+		if !ok {
+			return EncodingError{Word: i, Value: s}
+		}
+		words = append(words, fixed)
+	}
+
+	m.tag, m.word = words[0], words[1]
+	if m.args != nil {
+		m.args = words[2:]
+	}
+	return nil
+}
+
+// SetTextPolicy sets the policy the Tokeniser applies to the words of each line it finishes.
+// If the policy rejects a word, TokeniseBytes returns a SyntaxError wrapping an EncodingError instead of the line.
+func (t *Tokeniser) SetTextPolicy(p TextPolicy) {
+	t.text = p
+}
+
+// applyText applies the Tokeniser's TextPolicy to its current line.
+func (t *Tokeniser) applyText() error {
+	t.scratch = t.scratch[:0]
+	changed := false
+
+	start := 0
+	for i, end := range t.ends {
+		w, wchanged, ok := t.text.fixBytes(t.buf[start:end])
+		if !ok {
+			return EncodingError{Word: i, Value: string(t.buf[start:end])}
+		}
+		changed = changed || wchanged
+
+		// If nothing changes, the new ends are the same as the old ones.
+		t.scratch = append(t.scratch, w...)
+		t.ends[i] = len(t.scratch)
+		start = end
+	}
+
+	if changed {
+		t.buf, t.scratch = t.scratch, t.buf
+	}
+	return nil
+}
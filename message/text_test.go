@@ -0,0 +1,88 @@
+package message
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// ExampleMessage_ApplyTextPolicy is a testable example for ApplyTextPolicy.
+func ExampleMessage_ApplyTextPolicy() {
+	// "Café" with a decomposed é, and a stray Latin-1 byte.
+	m := New(TagBcast, "ITEM").AddArgs("Cafe\u0301", "caf\xe9")
+
+	fmt.Println(m.Clone().ApplyTextPolicy(TextPolicy{Invalid: UTF8Reject}))
+
+	if err := m.ApplyTextPolicy(TextPolicy{Invalid: UTF8Replace, NFC: true}); err == nil {
+		fmt.Printf("%+q\n", m.Args())
+	}
+
+	// Output:
+	// word 3 ("caf\xe9") is not valid UTF-8
+	// ["Caf\u00e9" "caf\ufffd"]
+}
+
+// TestReader_SetTextPolicy checks that a Reader applies its TextPolicy to each line.
+func TestReader_SetTextPolicy(t *testing.T) {
+	in := "a Cafe\u0301\nb caf\xe9 ok\nc ok\n"
+
+	cases := []struct {
+		policy TextPolicy
+		want   [][]string
+	}{
+		{TextPolicy{}, [][]string{{"a", "Cafe\u0301"}, {"b", "caf\xe9", "ok"}, {"c", "ok"}}},
+		{TextPolicy{NFC: true}, [][]string{{"a", "Caf\u00e9"}, {"b", "caf\xe9", "ok"}, {"c", "ok"}}},
+		{TextPolicy{Invalid: UTF8Replace}, [][]string{{"a", "Cafe\u0301"}, {"b", "caf\ufffd", "ok"}, {"c", "ok"}}},
+		// Rejected lines are nil.
+		{TextPolicy{Invalid: UTF8Reject, NFC: true}, [][]string{{"a", "Caf\u00e9"}, nil, {"c", "ok"}}},
+	}
+
+	for _, c := range cases {
+		for _, view := range []bool{false, true} {
+			r := NewReader(ioutil.NopCloser(bytes.NewReader([]byte(in))))
+			r.SetTextPolicy(c.policy)
+
+			var got [][]string
+			for {
+				line, err := readLineMaybeView(r, view)
+				if err == io.EOF {
+					break
+				}
+
+				var eerr EncodingError
+				if errors.As(err, &eerr) {
+					got = append(got, nil)
+					continue
+				}
+				if err != nil {
+					t.Fatalf("ReadLine with %v gave error %v", c.policy, err)
+				}
+				got = append(got, line)
+			}
+
+			if !cmpDamagedLines(got, c.want) {
+				t.Errorf("ReadLine (view: %v) with %v = %q; want %q", view, c.policy, got, c.want)
+			}
+		}
+	}
+}
+
+// readLineMaybeView reads a line from r with ReadLineView if view is true, and ReadLine otherwise.
+func readLineMaybeView(r *Reader, view bool) ([]string, error) {
+	if !view {
+		return r.ReadLine()
+	}
+
+	vs, err := r.ReadLineView()
+	if err != nil {
+		return nil, err
+	}
+	line := make([]string, len(vs))
+	for i, v := range vs {
+		line[i] = string(v)
+	}
+	return line, nil
+}
@@ -1,156 +1,442 @@
 package message
 
 import (
-	"unicode"
+	"fmt"
 )
 
-// quoteType represents one of the types of quoting used in the Bifrost protocol.
-type quoteType int
+// tokState is the enumeration of states of the Tokeniser's state machine.
+type tokState uint8
 
 const (
-	// none represents the state between quoted parts of a Bifrost message.
-	none quoteType = iota
+	// stateNone represents the state between quoted parts of a Bifrost message.
+	stateNone tokState = iota
 
-	// single represents 'single quoted' parts of a Bifrost message.
-	single
+	// stateSingle represents 'single quoted' parts of a Bifrost message.
+	stateSingle
 
-	// double represents "double quoted" parts of a Bifrost message.
-	double
+	// stateDouble represents "double quoted" parts of a Bifrost message.
+	stateDouble
+
+	// stateEscape represents the byte after a backslash outside quotes.
+	stateEscape
+
+	// stateDoubleEscape represents the byte after a backslash inside double quotes.
+	stateDoubleEscape
+
+	// numStates is the number of Tokeniser states.
+	numStates
+)
+
+// tokAction is the enumeration of things the Tokeniser can do on reading a byte.
+type tokAction uint8
+
+const (
+	// actNone does nothing besides changing state.
+	actNone tokAction = iota
+
+	// actPut adds the byte to the current word.
+	actPut
+
+	// actOpen starts a word without adding anything to it.
+	// Opening quotes do this, to allow '' and "" to represent the empty string.
+	actOpen
+
+	// actEndWord ends the current word, if there is one.
+	actEndWord
+
+	// actEndLine ends the current word, if there is one, and the current line.
+	actEndLine
+)
+
+// transition is an entry in the Tokeniser's state transition table.
+type transition struct {
+	next tokState
+	act  tokAction
+}
+
+// transitions is the Tokeniser's state transition table, indexed by current state and input byte.
+var transitions = buildTransitions()
+
+// buildTransitions builds the Tokeniser's state transition table.
+func buildTransitions() (t [numStates][256]transition) {
+	for b := range t[stateNone] {
+		t[stateNone][b] = transition{stateNone, actPut}
+		t[stateSingle][b] = transition{stateSingle, actPut}
+		t[stateDouble][b] = transition{stateDouble, actPut}
+		// Escaped bytes are always taken literally.
+		t[stateEscape][b] = transition{stateNone, actPut}
+		t[stateDoubleEscape][b] = transition{stateDouble, actPut}
+	}
+
+	// Only ASCII whitespace separates words: non-ASCII whitespace is more than one UTF-8 byte,
+	// and bytes such as 0xA0 can appear inside other characters.
+	for _, b := range []byte(" \t\v\f\r") {
+		t[stateNone][b] = transition{stateNone, actEndWord}
+	}
+	t[stateNone]['\n'] = transition{stateNone, actEndLine}
+	t[stateNone]['\''] = transition{stateSingle, actOpen}
+	t[stateNone]['"'] = transition{stateDouble, actOpen}
+	t[stateNone]['\\'] = transition{stateEscape, actNone}
+
+	// Single quotes are completely literal, so the only way out is another single quote.
+	t[stateSingle]['\''] = transition{stateNone, actNone}
+
+	t[stateDouble]['"'] = transition{stateNone, actNone}
+	t[stateDouble]['\\'] = transition{stateDoubleEscape, actNone}
+
+	return t
+}
+
+// Limit is the enumeration of resource limits a Tokeniser can enforce.
+type Limit int
+
+const (
+	// LimitLineLength is the limit on the number of bytes in a line, including its terminating newline.
+	LimitLineLength Limit = iota
+	// LimitWordLength is the limit on the number of bytes in a single (unescaped) word.
+	LimitWordLength
+	// LimitWordCount is the limit on the number of words in a line.
+	LimitWordCount
+)
+
+// String gets a human-readable description of a Limit.
+func (l Limit) String() string {
+	switch l {
+	case LimitLineLength:
+		return "line length"
+	case LimitWordLength:
+		return "word length"
+	case LimitWordCount:
+		return "word count"
+	default:
+		return "?unknown?"
+	}
+}
+
+// Limits holds the resource limits for a Tokeniser.
+// Each limit that is 0 is not enforced.
+type Limits struct {
+	// MaxLineLength is the maximum number of bytes in a line, including its terminating newline.
+	MaxLineLength int
+	// MaxWordLength is the maximum number of bytes in a single (unescaped) word.
+	MaxWordLength int
+	// MaxWords is the maximum number of words in a line.
+	MaxWords int
+}
+
+// LimitError is the error returned when a Tokeniser's input exceeds one of its Limits.
+type LimitError struct {
+	// Limit is the limit that was exceeded.
+	Limit Limit
+	// Max is the value of the exceeded limit.
+	Max int
+}
+
+// Error implements the error protocol for LimitError.
+func (l LimitError) Error() string {
+	return fmt.Sprintf("line exceeds %s limit of %d", l.Limit, l.Max)
+}
+
+// Pending is the enumeration of partial states a Tokeniser can be in between lines.
+type Pending int
+
+const (
+	// PendingNone means that the Tokeniser is between lines.
+	PendingNone Pending = iota
+	// PendingLine means that the Tokeniser is part-way through a line, but not inside quotes or an escape.
+	PendingLine
+	// PendingSingleQuote means that the Tokeniser is inside a 'single-quoted' string.
+	PendingSingleQuote
+	// PendingDoubleQuote means that the Tokeniser is inside a "double-quoted" string.
+	PendingDoubleQuote
+	// PendingEscape means that the Tokeniser has just read a backslash, and is waiting for the escaped byte.
+	PendingEscape
 )
 
+// String gets a human-readable description of a Pending state.
+func (p Pending) String() string {
+	switch p {
+	case PendingNone:
+		return "no pending input"
+	case PendingLine:
+		return "missing newline"
+	case PendingSingleQuote:
+		return "unclosed single quote"
+	case PendingDoubleQuote:
+		return "unclosed double quote"
+	case PendingEscape:
+		return "dangling escape"
+	default:
+		return "?unknown?"
+	}
+}
+
 // Tokeniser holds the state of a Bifrost protocol tokeniser.
+//
+// The Tokeniser is a table-driven state machine.
+// It stores the unescaped bytes of every word in the current line back-to-back in one buffer, which it reuses
+// from line to line; TokeniseBytesView hands out words as views into this buffer without copying them.
 type Tokeniser struct {
-	inWord           bool
-	escapeNextChar   bool
-	currentQuoteType quoteType
-	word             []byte
-	words            []string
+	state  tokState
+	inWord bool
+
+	// buf holds the unescaped bytes of every finished or partial word in the current line, back to back.
+	buf []byte
+	// ends holds the offset in buf at which each finished word in the current line ends.
+	ends []int
+	// views holds the views handed out by TokeniseBytesView.
+	views [][]byte
+	// lineDone is true if the last call finished a line, which must be cleared before tokenising more.
+	lineDone bool
+
+	input    inputFilter
+	limits   Limits
+	recovery Recovery
+	lineLen  int
+	// quotedNewlines counts the newlines inside quotes in the current line, for the Recovery policy.
+	quotedNewlines int
+
+	text TextPolicy
+	// scratch holds the current line while the TextPolicy is applied to it.
+	scratch []byte
+	// discarding is true if the current line broke a limit, and is being skipped.
+	discarding bool
+	// err holds any error raised by the current byte.
+	err error
+
+	// pos is the position of the next input byte.
+	pos Position
+	// cont is the number of UTF-8 continuation bytes still expected for the current character.
+	cont int
+	// inLine is true if the Tokeniser has read part of a line; lineStart is the position at which it started.
+	inLine    bool
+	lineStart Position
+	// snippet holds the start of the current line's raw input, for use in SyntaxErrors.
+	snippet []byte
 }
 
 // NewTokeniser creates and returns a new, empty Tokeniser.
 func NewTokeniser() *Tokeniser {
-	return &Tokeniser{
-		escapeNextChar:   false,
-		currentQuoteType: none,
-		word:             []byte{},
-		inWord:           false,
-		words:            []string{},
-	}
+	return &Tokeniser{state: stateNone, pos: Position{Line: 1, Column: 1}}
 }
 
-func (t *Tokeniser) endWord() {
-	if !t.inWord {
-		// Don't add an empty word.
-		return
+// Pending gets the partial state, if any, that the Tokeniser is in.
+// If the input stream ends when Pending is anything other than PendingNone, the final line was truncated.
+func (t *Tokeniser) Pending() Pending {
+	switch {
+	case t.state == stateEscape || t.state == stateDoubleEscape:
+		return PendingEscape
+	case t.state == stateSingle:
+		return PendingSingleQuote
+	case t.state == stateDouble:
+		return PendingDoubleQuote
+	case !t.lineDone && (t.inWord || len(t.ends) > 0 || t.discarding):
+		return PendingLine
+	default:
+		return PendingNone
 	}
+}
 
-	t.words = append(t.words, string(t.word))
-	t.word = []byte{}
-	t.inWord = false
+// Position gets the position of the next byte the Tokeniser will read.
+func (t *Tokeniser) Position() Position {
+	return t.pos
+}
+
+// LineStart gets the position at which the Tokeniser's current, or most recently finished, line started.
+func (t *Tokeniser) LineStart() Position {
+	return t.lineStart
+}
+
+// SetMode sets the InputMode the Tokeniser uses to pre-process its input.
+func (t *Tokeniser) SetMode(m InputMode) {
+	t.input.mode = m
+}
+
+// SetLimits sets the resource limits enforced by the Tokeniser.
+// The new limits take effect from the next byte tokenised.
+func (t *Tokeniser) SetLimits(l Limits) {
+	t.limits = l
 }
 
 // TokeniseBytes tokenises an array of bytes.
 // It returns the number of bytes read, whether or not it read a line, and the line contents if true.
-func (t *Tokeniser) TokeniseBytes(bs []byte) (nread int, lineok bool, line []string) {
-	if len(bs) == 0 {
-		return 0, false, nil
+//
+// If the current line breaks one of the Tokeniser's limits, TokeniseBytes stops and returns a SyntaxError
+// wrapping a LimitError.
+// The Tokeniser then discards the rest of that line, and carries on tokenising from the next one.
+// Similarly, if the Tokeniser abandons a line under its Recovery policy, TokeniseBytes returns a SyntaxError
+// wrapping a DamagedLineError, and carries on from the next line.
+func (t *Tokeniser) TokeniseBytes(bs []byte) (nread int, lineok bool, line []string, err error) {
+	if nread, lineok, err = t.tokenise(bs); lineok {
+		line = t.lineStrings()
+	}
+	return nread, lineok, line, err
+}
+
+// TokeniseBytesView is like TokeniseBytes, but returns the line as views into the Tokeniser's internal buffer.
+// This avoids allocating, but the views are only valid until the next call to the Tokeniser.
+func (t *Tokeniser) TokeniseBytesView(bs []byte) (nread int, lineok bool, line [][]byte, err error) {
+	if nread, lineok, err = t.tokenise(bs); lineok {
+		line = t.lineViews()
+	}
+	return nread, lineok, line, err
+}
+
+// tokenise tokenises bs until it runs out, finishes a line, or hits an error.
+// It returns the number of bytes read and whether or not it finished a line;
+// the line itself stays in the Tokeniser's buffer until the next call.
+func (t *Tokeniser) tokenise(bs []byte) (nread int, lineok bool, err error) {
+	if t.lineDone {
+		t.resetLine()
 	}
 
 	for i, b := range bs {
-		if t.tokeniseByte(b) {
-			line = t.words
-			t.words = []string{}
-			return i + 1, true, line
+		here := t.advance(b)
+		if t.input.mode == 0 {
+			lineok = t.step(b)
+		} else {
+			// Pre-processing can turn one byte into several, but only the last of them can end a line.
+			for _, fb := range t.input.filter(b) {
+				lineok = t.step(fb)
+			}
+		}
+
+		if lineok {
+			t.inLine = false
+		}
+		if lineok && t.discarding {
+			// This was the end of a line that broke a limit.
+			t.discarding = false
+			t.resetLine()
+			lineok = false
+		}
+		if t.err != nil {
+			err, t.err = t.syntaxError(here, t.err), nil
+			return i + 1, false, err
+		}
+		if lineok {
+			t.lineDone = true
+			if t.text != (TextPolicy{}) {
+				if err := t.applyText(); err != nil {
+					return i + 1, false, t.syntaxError(t.lineStart, err)
+				}
+			}
+			return i + 1, true, nil
 		}
 	}
 
-	return
+	return len(bs), false, nil
 }
 
-// tokeniseByte tokenises a single byte b.
-// It returns true if we've finished a line, which can only occur outside of
-// quotes
-func (t *Tokeniser) tokeniseByte(b byte) bool {
-	if t.escapeNextChar {
-		t.put(b)
-		t.escapeNextChar = false
+// step runs the Tokeniser's state machine on a single byte b.
+// It returns true if we've finished a line, which can only occur outside of quotes.
+func (t *Tokeniser) step(b byte) bool {
+	if t.recover(b) {
 		return false
 	}
 
-	funcs := map[quoteType]func(b byte) bool{
-		none:   t.tokeniseNoQuotes,
-		single: t.tokeniseSingleQuotes,
-		double: t.tokeniseDoubleQuotes,
+	if !t.discarding {
+		t.lineLen++
+		if t.limits.MaxLineLength > 0 && t.limits.MaxLineLength < t.lineLen {
+			t.fail(LimitLineLength, t.limits.MaxLineLength)
+		}
 	}
 
-	return funcs[t.currentQuoteType](b)
-}
+	tr := transitions[t.state][b]
+	t.state = tr.next
 
-// tokeniseNoQuotes tokenises a single byte outside quote characters.
-// It returns true if we've finished a line, and any error that occurred while
-// tokenising.
-func (t *Tokeniser) tokeniseNoQuotes(b byte) bool {
-	switch b {
-	case '\'':
-		// Switching into single quotes mode starts a word.
-		// This is to allow '' to represent the empty string.
-		t.inWord = true
-		t.currentQuoteType = single
-	case '"':
-		// Switching into double quotes mode starts a word.
-		// This is to allow "" to represent the empty string.
+	switch tr.act {
+	case actPut:
+		t.put(b)
+	case actOpen:
 		t.inWord = true
-		t.currentQuoteType = double
-	case '\\':
-		t.escapeNextChar = true
-	case '\n':
-		// We're ending the current word as well as a line.
+	case actEndWord:
 		t.endWord()
+	case actEndLine:
+		t.endWord()
+		t.lineLen = 0
+		t.quotedNewlines = 0
 		return true
-	default:
-		// Note that this will only check for ASCII
-		// whitespace, because we only pass it one byte
-		// and non-ASCII whitespace is >1 UTF-8 byte.
-		if unicode.IsSpace(rune(b)) {
-			t.endWord()
-		} else {
-			t.put(b)
-		}
 	}
-
 	return false
 }
 
-// tokeniseSingleQuotes tokenises a single byte within single quotes.
-// We can't finish a line in quotes, so it always returns false.
-func (t *Tokeniser) tokeniseSingleQuotes(b byte) bool {
-	switch b {
-	case '\'':
-		t.currentQuoteType = none
-	default:
-		t.put(b)
+// wordStart gets the offset in buf at which the current word starts.
+func (t *Tokeniser) wordStart() int {
+	if len(t.ends) == 0 {
+		return 0
 	}
+	return t.ends[len(t.ends)-1]
+}
 
-	return false
+// put adds a byte to the Tokeniser's word.
+func (t *Tokeniser) put(b byte) {
+	if t.discarding {
+		return
+	}
+	if t.limits.MaxWordLength > 0 && t.limits.MaxWordLength <= len(t.buf)-t.wordStart() {
+		t.fail(LimitWordLength, t.limits.MaxWordLength)
+		return
+	}
+
+	t.inWord = true
+	t.buf = append(t.buf, b)
 }
 
-// tokeniseDoubleQuotes tokenises a single byte within double quotes.
-// We can't finish a line in quotes, so it always returns false.
-func (t *Tokeniser) tokeniseDoubleQuotes(b byte) bool {
-	switch b {
-	case '"':
-		t.currentQuoteType = none
-	case '\\':
-		t.escapeNextChar = true
-	default:
-		t.put(b)
+// endWord finishes the Tokeniser's current word, if there is one.
+func (t *Tokeniser) endWord() {
+	if !t.inWord {
+		// Don't add an empty word.
+		return
+	}
+	t.inWord = false
+	if t.discarding {
+		return
 	}
 
-	return false
+	if t.limits.MaxWords > 0 && t.limits.MaxWords <= len(t.ends) {
+		t.fail(LimitWordCount, t.limits.MaxWords)
+		return
+	}
+	t.ends = append(t.ends, len(t.buf))
 }
 
-// put adds a byte to the Tokeniser's word.
-func (t *Tokeniser) put(b byte) {
-	t.inWord = true
-	t.word = append(t.word, b)
+// fail marks the current line as having broken limit l, whose value is max.
+// The Tokeniser then discards the rest of the line.
+func (t *Tokeniser) fail(l Limit, max int) {
+	t.err = LimitError{Limit: l, Max: max}
+	t.discarding = true
+	t.resetLine()
+}
+
+// resetLine clears the current line, keeping the buffers for reuse.
+func (t *Tokeniser) resetLine() {
+	t.inWord = false
+	t.lineDone = false
+	t.buf = t.buf[:0]
+	t.ends = t.ends[:0]
+}
+
+// lineStrings copies the current line out of the Tokeniser as a slice of strings.
+// It allocates all of the strings at once.
+func (t *Tokeniser) lineStrings() []string {
+	all := string(t.buf)
+	line := make([]string, len(t.ends))
+	start := 0
+	for i, end := range t.ends {
+		line[i] = all[start:end]
+		start = end
+	}
+	return line
+}
+
+// lineViews gets the current line as views into the Tokeniser's buffer.
+func (t *Tokeniser) lineViews() [][]byte {
+	t.views = t.views[:0]
+	start := 0
+	for _, end := range t.ends {
+		t.views = append(t.views, t.buf[start:end:end])
+		start = end
+	}
+	return t.views
 }
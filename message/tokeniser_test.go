@@ -2,6 +2,7 @@ package message
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"io/ioutil"
 	"testing"
@@ -160,6 +161,11 @@ func TestReaderTokeniser(t *testing.T) {
 			[][]string{{"北野", "武"}},
 		},
 		// U2 intentionally left blank.
+		// U3 - UTF-8 containing bytes that are whitespace in Latin-1
+		{
+			"voilà \u2026\n",
+			[][]string{{"voilà", "\u2026"}},
+		},
 		// X1 - Sample BAPS3 command, with double-quoted Windows path
 		{
 			`enqueue file "C:\\Users\\Test\\Artist - Title.mp3" 1` + "\n",
@@ -197,3 +203,105 @@ func TestReaderTokeniser(t *testing.T) {
 		}
 	}
 }
+
+// TestReader_SetLimits checks that a Reader reports lines breaking its limits, and then recovers.
+func TestReader_SetLimits(t *testing.T) {
+	cases := []struct {
+		limits Limits
+		in     string
+		want   Limit
+	}{
+		{Limits{MaxLineLength: 10}, "a very long line indeed\n", LimitLineLength},
+		{Limits{MaxLineLength: 10}, "'a quote\n\n\n\n\n\n\n\nspanning many lines'\n", LimitLineLength},
+		{Limits{MaxWordLength: 4}, "fine fine toolong fine\n", LimitWordLength},
+		{Limits{MaxWordLength: 4}, "\"quoted\" fine\n", LimitWordLength},
+		{Limits{MaxWords: 2}, "one two three\n", LimitWordCount},
+	}
+
+	for _, c := range cases {
+		// Each bad line is followed by a good one, to check that the Reader recovers.
+		in := c.in + "ok ok\n"
+		r := NewReader(ioutil.NopCloser(bytes.NewReader([]byte(in))))
+		r.SetLimits(c.limits)
+
+		var lerr LimitError
+		if _, err := r.ReadLine(); err == nil {
+			t.Errorf("ReadLine(%q) with %v gave no error", c.in, c.limits)
+			continue
+		} else if !errors.As(err, &lerr) {
+			t.Errorf("ReadLine(%q) with %v gave non-limit error %v", c.in, c.limits, err)
+			continue
+		} else if lerr.Limit != c.want {
+			t.Errorf("ReadLine(%q) with %v broke limit %s; want %s", c.in, c.limits, lerr.Limit, c.want)
+		}
+
+		if got, err := r.ReadLine(); err != nil {
+			t.Errorf("ReadLine after %q gave error %v", c.in, err)
+		} else if !cmpWords(got, []string{"ok", "ok"}) {
+			t.Errorf("ReadLine after %q == %q; want [ok ok]", c.in, got)
+		}
+	}
+}
+
+// TestReader_truncated checks that a Reader distinguishes truncated input from a clean EOF.
+func TestReader_truncated(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Pending
+	}{
+		{"", PendingNone},
+		{"foo bar\n", PendingNone},
+		{"foo bar\n   ", PendingNone},
+		{"foo bar", PendingLine},
+		{"foo 'bar", PendingSingleQuote},
+		{"foo \"bar\nbaz", PendingDoubleQuote},
+		{"foo bar\\", PendingEscape},
+		{"foo \"bar\\", PendingEscape},
+	}
+
+	for _, c := range cases {
+		r := NewReader(ioutil.NopCloser(bytes.NewReader([]byte(c.in))))
+
+		var err error
+		for err == nil {
+			_, err = r.ReadLine()
+		}
+
+		var terr TruncatedError
+		if c.want == PendingNone {
+			if err != io.EOF {
+				t.Errorf("ReadLine(%q) gave error %v; want EOF", c.in, err)
+			}
+		} else if !errors.As(err, &terr) {
+			t.Errorf("ReadLine(%q) gave error %v; want TruncatedError", c.in, err)
+		} else if terr.Pending != c.want {
+			t.Errorf("ReadLine(%q) truncated with %s; want %s", c.in, terr.Pending, c.want)
+		} else if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("ReadLine(%q) error doesn't wrap io.ErrUnexpectedEOF", c.in)
+		}
+	}
+}
+
+// TestReader_ReadLineView checks that ReadLineView agrees with ReadLine.
+func TestReader_ReadLineView(t *testing.T) {
+	in := "foo 'bar baz' \"\"\n\nx\\ y\n"
+	want := [][]string{{"foo", "bar baz", ""}, {}, {"x y"}}
+
+	r := NewReader(ioutil.NopCloser(bytes.NewReader([]byte(in))))
+	for _, wline := range want {
+		line, err := r.ReadLineView()
+		if err != nil {
+			t.Fatalf("ReadLineView(%q) gave error %v", in, err)
+		}
+		got := make([]string, len(line))
+		for i, w := range line {
+			got[i] = string(w)
+		}
+		if !cmpWords(got, wline) {
+			t.Errorf("ReadLineView(%q) == %q, want %q", in, got, wline)
+		}
+	}
+	if _, err := r.ReadLineView(); err != io.EOF {
+		t.Errorf("ReadLineView(%q) at end gave %v; want EOF", in, err)
+	}
+}
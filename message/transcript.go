@@ -0,0 +1,156 @@
+package message
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// File message/transcript.go contains the transcript format for recording Bifrost sessions.
+//
+// A transcript is a text file with one entry per line.
+// Each entry is itself a Bifrost line, so it can be read with the usual Tokeniser and quoting rules:
+//
+//	2006-01-02T15:04:05.999999999Z in conn-1 f00f read /player/time
+//	2006-01-02T15:04:05.999999999Z out conn-1 f00f ACK OK success
+//
+// The first word is the time of the entry in RFC 3339 format, in UTC; the second is its direction;
+// the third is the ID of the connection; and the rest is the packed message.
+
+// Direction is the enumeration of directions a message can travel in a transcript.
+type Direction int
+
+const (
+	// DirIn marks messages received from a connection.
+	DirIn Direction = iota
+	// DirOut marks messages sent to a connection.
+	DirOut
+)
+
+// String gets the transcript form of a Direction.
+func (d Direction) String() string {
+	switch d {
+	case DirIn:
+		return "in"
+	case DirOut:
+		return "out"
+	default:
+		return "?unknown?"
+	}
+}
+
+// parseDirection parses the transcript form of a Direction.
+func parseDirection(s string) (Direction, bool) {
+	switch s {
+	case "in":
+		return DirIn, true
+	case "out":
+		return DirOut, true
+	default:
+		return 0, false
+	}
+}
+
+// TranscriptEntry is a single entry in a transcript.
+type TranscriptEntry struct {
+	// Time is the time at which the message was sent or received.
+	Time time.Time
+	// Dir is the direction in which the message travelled.
+	Dir Direction
+	// Conn identifies the connection on which the message travelled.
+	Conn string
+	// Message is the message itself.
+	Message *Message
+}
+
+// TranscriptWriter wraps a Writer to write transcript entries.
+// It is safe to use from multiple goroutines; each entry is written with a single call to the Writer.
+type TranscriptWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf []byte
+}
+
+// NewTranscriptWriter creates and returns a new TranscriptWriter.
+func NewTranscriptWriter(w io.Writer) *TranscriptWriter {
+	return &TranscriptWriter{w: w}
+}
+
+// Record writes an entry for message m, which travelled in direction dir on connection conn just now.
+func (w *TranscriptWriter) Record(dir Direction, conn string, m *Message) error {
+	return w.WriteEntry(TranscriptEntry{Time: time.Now(), Dir: dir, Conn: conn, Message: m})
+}
+
+// WriteEntry writes e to the TranscriptWriter.
+// It fails with a ValidationError, writing nothing, if e's message can't be packed.
+func (w *TranscriptWriter) WriteEntry(e TranscriptEntry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b := e.Time.UTC().AppendFormat(w.buf[:0], time.RFC3339Nano)
+	b = append(b, ' ')
+	b = append(b, e.Dir.String()...)
+	b = append(b, ' ')
+	b = appendArg(b, e.Conn, QuoteSingle)
+	b = append(b, ' ')
+
+	b, err := e.Message.AppendPack(b)
+	if err != nil {
+		return err
+	}
+	w.buf = b
+
+	_, err = w.w.Write(b)
+	return err
+}
+
+// TranscriptReader wraps a ReadCloser to read transcript entries.
+type TranscriptReader struct {
+	r *Reader
+}
+
+// NewTranscriptReader creates and returns a new TranscriptReader.
+// If closing is not required, use ioutil.NopCloser.
+func NewTranscriptReader(reader io.ReadCloser) *TranscriptReader {
+	return &TranscriptReader{r: NewReader(reader)}
+}
+
+// ReadEntry reads an entry from the TranscriptReader.
+// It returns io.EOF at the end of the transcript, and a SyntaxError if an entry is malformed;
+// after a SyntaxError, it is safe to carry on reading.
+func (r *TranscriptReader) ReadEntry() (*TranscriptEntry, error) {
+	line, err := r.r.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 5 {
+		return nil, r.entryError(fmt.Errorf("entry has %d words, want at least 5", len(line)))
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, line[0])
+	if err != nil {
+		return nil, r.entryError(fmt.Errorf("bad timestamp: %w", err))
+	}
+	dir, ok := parseDirection(line[1])
+	if !ok {
+		return nil, r.entryError(fmt.Errorf("bad direction %q", line[1]))
+	}
+
+	return &TranscriptEntry{
+		Time:    t,
+		Dir:     dir,
+		Conn:    line[2],
+		Message: New(line[3], line[4]).AddArgs(line[5:]...),
+	}, nil
+}
+
+// entryError wraps err in a SyntaxError pointing at the entry just read.
+func (r *TranscriptReader) entryError(err error) error {
+	return r.r.tok.syntaxError(r.r.LineStart(), err)
+}
+
+// Close closes the TranscriptReader's underlying ReadCloser.
+func (r *TranscriptReader) Close() error {
+	return r.r.Close()
+}
@@ -0,0 +1,93 @@
+package message
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ExampleTranscriptWriter is a testable example for TranscriptWriter.
+func ExampleTranscriptWriter() {
+	t := time.Date(2020, time.March, 14, 15, 9, 26, 535897000, time.UTC)
+
+	w := NewTranscriptWriter(os.Stdout)
+	_ = w.WriteEntry(TranscriptEntry{Time: t, Dir: DirIn, Conn: "conn-1", Message: New("f00f", "read").AddArgs("/player/time")})
+	_ = w.WriteEntry(TranscriptEntry{Time: t.Add(time.Millisecond), Dir: DirOut, Conn: "conn 2", Message: New("f00f", "ACK").AddArgs("OK", "it's fine")})
+
+	// Output:
+	// 2020-03-14T15:09:26.535897Z in conn-1 f00f read /player/time
+	// 2020-03-14T15:09:26.536897Z out 'conn 2' f00f ACK OK 'it'\''s fine'
+}
+
+// TestTranscript_roundTrip checks that TranscriptReader reads back what TranscriptWriter writes.
+func TestTranscript_roundTrip(t *testing.T) {
+	start := time.Date(2020, time.March, 14, 15, 9, 26, 0, time.FixedZone("BST", 3600))
+	want := []TranscriptEntry{
+		{start, DirIn, "1", New("a", "write").AddArgs("/x", "new\nline")},
+		{start.Add(time.Nanosecond), DirOut, "", New(TagBcast, "ITEM")},
+		{start.Add(time.Hour), DirIn, "[::1]:1350", New("b", "read")},
+	}
+
+	var buf bytes.Buffer
+	w := NewTranscriptWriter(&buf)
+	for _, e := range want {
+		if err := w.WriteEntry(e); err != nil {
+			t.Fatalf("WriteEntry(%v) gave error %v", e, err)
+		}
+	}
+
+	r := NewTranscriptReader(ioutil.NopCloser(&buf))
+	for _, we := range want {
+		got, err := r.ReadEntry()
+		if err != nil {
+			t.Fatalf("ReadEntry gave error %v", err)
+		}
+		if !got.Time.Equal(we.Time) || got.Dir != we.Dir || got.Conn != we.Conn || !got.Message.Equal(we.Message) {
+			t.Errorf("ReadEntry = %v; want %v", got, we)
+		}
+	}
+	if _, err := r.ReadEntry(); err != io.EOF {
+		t.Errorf("ReadEntry at end gave error %v; want EOF", err)
+	}
+}
+
+// TestTranscriptReader_errors checks that TranscriptReader reports malformed entries, and then carries on.
+func TestTranscriptReader_errors(t *testing.T) {
+	cases := []string{
+		"2020-03-14T15:09:26Z in 1 a\n",
+		"yesterday in 1 a b\n",
+		"2020-03-14T15:09:26Z sideways 1 a b\n",
+	}
+
+	for _, c := range cases {
+		r := NewTranscriptReader(ioutil.NopCloser(strings.NewReader(c + "2020-03-14T15:09:26Z out 1 a b\n")))
+
+		var serr SyntaxError
+		if e, err := r.ReadEntry(); !errors.As(err, &serr) {
+			t.Errorf("ReadEntry(%q) = %v, %v; want SyntaxError", c, e, err)
+		}
+		if e, err := r.ReadEntry(); err != nil || e.Dir != DirOut {
+			t.Errorf("ReadEntry after %q = %v, %v", c, e, err)
+		}
+	}
+}
+
+// TestTranscriptWriter_invalid checks that TranscriptWriter writes nothing for messages that can't be packed.
+func TestTranscriptWriter_invalid(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewTranscriptWriter(&buf)
+	err := w.Record(DirIn, "1", New("bad tag", "read"))
+
+	var verr ValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("Record gave error %v; want ValidationError", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Record wrote %q; want nothing", buf.String())
+	}
+}
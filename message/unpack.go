@@ -0,0 +1,54 @@
+package message
+
+import (
+	"errors"
+)
+
+// File message/unpack.go contains functions for parsing single packed messages.
+
+// ErrMultipleLines is the error returned when unpacking input that contains more than one line.
+var ErrMultipleLines = errors.New("input contains more than one line")
+
+// Unpack parses the packed message b into a Message; it is the inverse of Pack.
+//
+// The final newline is optional.
+// Unpack fails with a SyntaxError, wrapping a TruncatedError if b ends in the middle of a quoted string or escape,
+// ErrMultipleLines if b contains more than one line, and ErrTooFewWords if b has no tag or word.
+func Unpack(b []byte) (*Message, error) {
+	line, err := unpackWords(b)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := NewFromLine(line)
+	if err != nil {
+		return nil, SyntaxError{Position: Position{Line: 1, Column: 1}, Snippet: snippetOf(b), Err: err}
+	}
+	return m, nil
+}
+
+// ParseString is like Unpack, but parses a string.
+// It is useful for message literals in test fixtures, configuration files, and command lines.
+func ParseString(s string) (*Message, error) {
+	return Unpack([]byte(s))
+}
+
+// unpackWords tokenises b, which must contain exactly one line, optionally missing its final newline.
+func unpackWords(b []byte) ([]string, error) {
+	t := NewTokeniser()
+	nread, lineok, line, err := t.TokeniseBytes(b)
+	if p := t.Pending(); err == nil && !lineok && (p == PendingLine || p == PendingNone) {
+		// The input is only missing its newline (or is blank), so supply it.
+		_, lineok, line, err = t.TokeniseBytes([]byte{'\n'})
+	}
+
+	switch {
+	case err != nil:
+		return nil, err
+	case !lineok:
+		return nil, t.syntaxError(t.pos, TruncatedError{Pending: t.Pending()})
+	case nread != len(b):
+		return nil, SyntaxError{Position: t.pos, Snippet: snippetOf(b[nread:]), Err: ErrMultipleLines}
+	}
+	return line, nil
+}
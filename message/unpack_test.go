@@ -0,0 +1,87 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// ExampleParseString is a testable example for ParseString.
+func ExampleParseString() {
+	m, err := ParseString(`f00f write /player/file "/music/Rick Astley.mp3"`)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(m.Tag(), m.Word(), len(m.Args()), m.Args()[1])
+
+	// Output:
+	// f00f write 2 /music/Rick Astley.mp3
+}
+
+// TestUnpack_roundTrip checks that Unpack inverts Pack in every quoting style.
+func TestUnpack_roundTrip(t *testing.T) {
+	msgs := []*Message{
+		New("a", "b"),
+		New(TagBcast, "ITEM").AddArgs("", "it's", `back\slash "quoted"`, "new\nline", "tab\there"),
+	}
+	styles := []QuoteStyle{QuoteSingle, QuoteMinimal, QuoteDouble, QuoteAlways}
+
+	for _, m := range msgs {
+		for _, style := range styles {
+			packed, err := m.PackWith(style)
+			if err != nil {
+				t.Fatalf("PackWith(%q, %d) gave error %v", m, style, err)
+			}
+			for _, b := range [][]byte{packed, packed[:len(packed)-1]} {
+				got, err := Unpack(b)
+				if err != nil {
+					t.Errorf("Unpack(%q) gave error %v", b, err)
+					continue
+				}
+				if !got.Equal(m) {
+					t.Errorf("Unpack(%q) = %q; want %q", b, got, m)
+				}
+			}
+		}
+	}
+}
+
+// TestUnpack_errors checks that Unpack rejects incomplete and multi-line input.
+func TestUnpack_errors(t *testing.T) {
+	cases := []struct {
+		in      string
+		pending Pending
+		multi   bool
+		few     bool
+	}{
+		{"x y 'unclosed", PendingSingleQuote, false, false},
+		{`x y "unclosed`, PendingDoubleQuote, false, false},
+		{`x y trailing\`, PendingEscape, false, false},
+		{"x y\nz w", PendingNone, true, false},
+		{"x y\n\n", PendingNone, true, false},
+		{"", PendingNone, false, true},
+		{"   ", PendingNone, false, true},
+		{"\n", PendingNone, false, true},
+		{"x", PendingNone, false, true},
+	}
+
+	for _, c := range cases {
+		m, err := ParseString(c.in)
+		if err == nil {
+			t.Errorf("ParseString(%q) = %q; want error", c.in, m)
+			continue
+		}
+
+		var terr TruncatedError
+		if c.pending != PendingNone && (!errors.As(err, &terr) || terr.Pending != c.pending) {
+			t.Errorf("ParseString(%q) gave error %v; want truncation with %v", c.in, err, c.pending)
+		}
+		if c.multi != errors.Is(err, ErrMultipleLines) {
+			t.Errorf("ParseString(%q) gave error %v; multiple lines expected: %v", c.in, err, c.multi)
+		}
+		if c.few != errors.Is(err, ErrTooFewWords) {
+			t.Errorf("ParseString(%q) gave error %v; too few words expected: %v", c.in, err, c.few)
+		}
+	}
+}
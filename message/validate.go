@@ -0,0 +1,107 @@
+package message
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// File message/validate.go contains well-formedness checks for Messages.
+
+// ValidationError is the error returned when a Message breaks the rules of the Bifrost protocol.
+type ValidationError struct {
+	// Field names the offending part of the message ("tag" or "word").
+	Field string
+
+	// Value is the offending value.
+	Value string
+
+	// Reason describes the rule the value breaks.
+	Reason string
+}
+
+// Error implements the error protocol for ValidationError.
+func (v ValidationError) Error() string {
+	return fmt.Sprintf("invalid message %s %q: %s", v.Field, v.Value, v.Reason)
+}
+
+// NewStrict is like New, but fails with a ValidationError if the tag or word break the rules checked by Validate.
+func NewStrict(tag, word string) (*Message, error) {
+	m := New(tag, word)
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate checks that m follows the rules of the Bifrost protocol, returning a ValidationError if not.
+//
+// The tag and word must be non-empty, and contain no whitespace, quotes, backslashes, or control characters;
+// Pack also enforces these rules, as breaking them produces lines that peers would parse differently.
+// Validate also checks that TagBcast and TagUnknown are only used on responses (whose words are uppercase),
+// as requests need a tag that can be matched against their replies.
+func (m *Message) Validate() error {
+	if err := m.checkWellFormed(); err != nil {
+		return err
+	}
+
+	if (m.tag == TagBcast || m.tag == TagUnknown) && !IsResponseWord(m.word) {
+		return ValidationError{
+			Field:  "tag",
+			Value:  m.tag,
+			Reason: fmt.Sprintf("only responses can have this tag, and %q is not a response word", m.word),
+		}
+	}
+	return nil
+}
+
+// IsResponseWord checks whether word is a response word.
+// By convention, Bifrost response words are uppercase, and request words lowercase.
+func IsResponseWord(word string) bool {
+	for _, r := range word {
+		if unicode.IsLower(r) {
+			return false
+		}
+	}
+	return word != ""
+}
+
+// checkWellFormed checks that m's tag and word can be packed without corrupting the line.
+func (m *Message) checkWellFormed() error {
+	if err := checkField("tag", m.tag); err != nil {
+		return err
+	}
+	return checkField("word", m.word)
+}
+
+// checkField checks that the value of field name can be packed without quoting.
+func checkField(name, value string) error {
+	if value == "" {
+		return ValidationError{Field: name, Value: value, Reason: "must not be empty"}
+	}
+	if !utf8.ValidString(value) {
+		return ValidationError{Field: name, Value: value, Reason: "must be valid UTF-8"}
+	}
+	for _, r := range value {
+		if reason := badFieldRune(r); reason != "" {
+			return ValidationError{Field: name, Value: value, Reason: reason}
+		}
+	}
+	return nil
+}
+
+// badFieldRune gets the reason why r can't appear in a tag or word, or "" if it can.
+func badFieldRune(r rune) string {
+	switch {
+	case r == '\'' || r == '"':
+		return "must not contain quotes"
+	case r == '\\':
+		return "must not contain backslashes"
+	case unicode.IsSpace(r):
+		return "must not contain whitespace"
+	case unicode.IsControl(r):
+		return "must not contain control characters"
+	default:
+		return ""
+	}
+}
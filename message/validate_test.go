@@ -0,0 +1,70 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// ExampleNewStrict is a testable example for NewStrict.
+func ExampleNewStrict() {
+	if _, err := NewStrict("my tag", "read"); err != nil {
+		fmt.Println(err)
+	}
+	if _, err := NewStrict(TagBcast, "read"); err != nil {
+		fmt.Println(err)
+	}
+	if m, err := NewStrict(TagBcast, "OHAI"); err == nil {
+		fmt.Print(m)
+	}
+
+	// Output:
+	// invalid message tag "my tag": must not contain whitespace
+	// invalid message tag "!": only responses can have this tag, and "read" is not a response word
+	// ! OHAI
+}
+
+// TestMessage_Validate checks Validate and Pack against well- and ill-formed messages.
+func TestMessage_Validate(t *testing.T) {
+	cases := []struct {
+		msg       *Message
+		wantField string
+		// packOk is true if Pack should succeed even though Validate fails.
+		packOk bool
+	}{
+		{New("x", "write"), "", true},
+		{New(TagBcast, "OHAI"), "", true},
+		{New(TagUnknown, "ACK"), "", true},
+		{New("北野", "武"), "", true},
+		{New("", "write"), "tag", false},
+		{New("x", ""), "word", false},
+		{New("a b", "write"), "tag", false},
+		{New("x", "wri\nte"), "word", false},
+		{New("'x'", "write"), "tag", false},
+		{New("x", `"write"`), "word", false},
+		{New(`x\`, "write"), "tag", false},
+		{New("x\x07", "write"), "tag", false},
+		{New("x", "write\xff"), "word", false},
+		{New(TagBcast, "write"), "tag", true},
+		{New(TagUnknown, "read"), "tag", true},
+	}
+
+	for _, c := range cases {
+		err := c.msg.Validate()
+
+		var verr ValidationError
+		if c.wantField == "" {
+			if err != nil {
+				t.Errorf("Validate(%q) gave unexpected error %v", c.msg, err)
+			}
+		} else if !errors.As(err, &verr) {
+			t.Errorf("Validate(%q) gave error %v; want ValidationError", c.msg, err)
+		} else if verr.Field != c.wantField {
+			t.Errorf("Validate(%q) blamed %s; want %s", c.msg, verr.Field, c.wantField)
+		}
+
+		if _, err := c.msg.Pack(); (err == nil) != (c.wantField == "" || c.packOk) {
+			t.Errorf("Pack(%q) gave error %v", c.msg, err)
+		}
+	}
+}
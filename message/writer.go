@@ -0,0 +1,173 @@
+package message
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+)
+
+// Writer wraps a Writer to provide buffered, message-level writing functionality.
+//
+// Messages written to a Writer are packed into an internal buffer, and only reach the underlying Writer
+// when the buffer fills, when Flush is called, or (if enabled with SetAutoFlush) a given duration after the first
+// message written since the last flush.
+// This lets busy servers send bursts of messages without issuing one write per message.
+//
+// A Writer is safe for concurrent use.
+type Writer struct {
+	mu  sync.Mutex
+	buf *bufio.Writer
+
+	// idle is the auto-flush idle duration, or 0 if auto-flushing is disabled.
+	idle time.Duration
+	// timer is the auto-flush timer, created on first use and reused thereafter.
+	timer *time.Timer
+	// armed is true if an auto-flush is pending; a timer that fires while it is false does nothing.
+	armed bool
+	// err is any error that occurred during an auto-flush, to be reported on the next call.
+	err error
+	// scratch is reused for packing messages.
+	scratch []byte
+	// style is the quoting style used for packing messages.
+	style QuoteStyle
+}
+
+// NewWriter creates and returns a new Writer over the given Writer.
+// Auto-flushing is initially disabled; use SetAutoFlush to enable it.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{buf: bufio.NewWriter(w)}
+}
+
+// SetAutoFlush sets the Writer to flush itself once idle has passed since the first message written after
+// the last flush.
+// This caps how long a message waits in the buffer, even under a steady stream of writes.
+// An idle duration of 0 disables auto-flushing.
+func (w *Writer) SetAutoFlush(idle time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.idle = idle
+	if idle == 0 {
+		w.stopTimer()
+	}
+}
+
+// SetQuoteStyle sets the quoting style the Writer uses to pack messages.
+// The default is QuoteSingle, as with Pack.
+func (w *Writer) SetQuoteStyle(style QuoteStyle) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.style = style
+}
+
+// WriteMessage packs m into the Writer's buffer.
+// It doesn't flush, unless the buffer fills up.
+func (w *Writer) WriteMessage(m *Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.writeLocked(m); err != nil {
+		return err
+	}
+	w.armTimer()
+	return nil
+}
+
+// WriteMessages packs the batch ms into the Writer's buffer, then flushes it.
+// It stops at the first message that fails to pack or write.
+func (w *Writer) WriteMessages(ms ...*Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, m := range ms {
+		if err := w.writeLocked(m); err != nil {
+			return err
+		}
+	}
+	return w.flushLocked()
+}
+
+// Flush writes any buffered messages to the underlying Writer.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.flushLocked()
+}
+
+// Buffered returns the number of bytes waiting in the Writer's buffer.
+func (w *Writer) Buffered() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.Buffered()
+}
+
+// writeLocked packs m into the buffer; w.mu must be held.
+func (w *Writer) writeLocked(m *Message) error {
+	if err := w.takeErr(); err != nil {
+		return err
+	}
+
+	var err error
+	if w.scratch, err = m.AppendPackWith(w.scratch[:0], w.style); err != nil {
+		return err
+	}
+	_, err = w.buf.Write(w.scratch)
+	return err
+}
+
+// flushLocked flushes the buffer; w.mu must be held.
+func (w *Writer) flushLocked() error {
+	w.stopTimer()
+	if err := w.takeErr(); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
+
+// takeErr returns, and clears, any error left over from an auto-flush.
+func (w *Writer) takeErr() error {
+	err := w.err
+	w.err = nil
+	return err
+}
+
+// armTimer starts the auto-flush timer, if auto-flushing is enabled, the buffer has just become non-empty,
+// and the timer isn't already running.
+func (w *Writer) armTimer() {
+	if w.idle == 0 || w.armed || w.buf.Buffered() == 0 {
+		return
+	}
+	w.armed = true
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.idle, w.autoFlush)
+		return
+	}
+	w.timer.Reset(w.idle)
+}
+
+// stopTimer cancels any pending auto-flush.
+func (w *Writer) stopTimer() {
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.armed = false
+}
+
+// autoFlush is called by the auto-flush timer.
+func (w *Writer) autoFlush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.armed {
+		// The auto-flush was cancelled while waiting for the lock.
+		return
+	}
+	w.armed = false
+	if err := w.buf.Flush(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
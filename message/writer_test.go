@@ -0,0 +1,118 @@
+package message
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingWriter is a bytes.Buffer that counts how many times Write is called.
+type countingWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writes++
+	return c.buf.Write(p)
+}
+
+func (c *countingWriter) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.String()
+}
+
+var writerTestMessages = []*Message{
+	New(TagBcast, "POS").AddArgs("1000"),
+	New(TagBcast, "POS").AddArgs("2000"),
+	New("f00f", "ACK").AddArgs("OK", "all good"),
+}
+
+const writerTestWant = "! POS 1000\n! POS 2000\nf00f ACK OK 'all good'\n"
+
+// TestWriter_WriteMessages checks that WriteMessages writes a batch in one go.
+func TestWriter_WriteMessages(t *testing.T) {
+	var cw countingWriter
+	w := NewWriter(&cw)
+
+	if err := w.WriteMessages(writerTestMessages...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cw.String(); got != writerTestWant {
+		t.Errorf("WriteMessages wrote %q; want %q", got, writerTestWant)
+	}
+	if cw.writes != 1 {
+		t.Errorf("WriteMessages made %d writes; want 1", cw.writes)
+	}
+}
+
+// TestWriter_Flush checks that WriteMessage buffers until Flush.
+func TestWriter_Flush(t *testing.T) {
+	var cw countingWriter
+	w := NewWriter(&cw)
+
+	for _, m := range writerTestMessages {
+		if err := w.WriteMessage(m); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := cw.String(); got != "" {
+		t.Errorf("WriteMessage wrote %q before Flush", got)
+	}
+	if w.Buffered() != len(writerTestWant) {
+		t.Errorf("Buffered()=%d; want %d", w.Buffered(), len(writerTestWant))
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cw.String(); got != writerTestWant {
+		t.Errorf("Flush wrote %q; want %q", got, writerTestWant)
+	}
+}
+
+// TestWriter_SetAutoFlush checks that an auto-flushing Writer flushes itself once idle.
+func TestWriter_SetAutoFlush(t *testing.T) {
+	var cw countingWriter
+	w := NewWriter(&cw)
+	w.SetAutoFlush(time.Millisecond)
+
+	for _, m := range writerTestMessages {
+		if err := w.WriteMessage(m); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for cw.String() != writerTestWant {
+		if time.Now().After(deadline) {
+			t.Fatalf("auto-flush didn't happen; got %q", cw.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestWriter_SetAutoFlush_steady checks that an auto-flushing Writer flushes a steady stream of writes,
+// even if no two writes are more than idle apart.
+func TestWriter_SetAutoFlush_steady(t *testing.T) {
+	var cw countingWriter
+	w := NewWriter(&cw)
+	w.SetAutoFlush(20 * time.Millisecond)
+
+	// The stream must stay well short of filling the buffer, else that would flush it instead.
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for cw.String() == "" {
+		if time.Now().After(deadline) {
+			t.Fatal("auto-flush didn't happen during a steady stream of writes")
+		}
+		if err := w.WriteMessage(writerTestMessages[0]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
@@ -1,6 +1,10 @@
 package list
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/UniversityRadioYork/bifrost-go/message"
+)
 
 // AutoMode is the type of autoselection modes.
 type AutoMode int
@@ -20,34 +24,33 @@ const (
 	LastAuto = AutoNext
 )
 
+// autoModeNames holds the Bifrost name of each AutoMode, indexed by AutoMode.
+var autoModeNames = []string{"off", "drop", "next", "shuffle"}
+
 // String gets the Bifrost name of an AutoMode as a string.
 func (a AutoMode) String() string {
-	switch a {
-	case AutoOff:
-		return "off"
-	case AutoDrop:
-		return "drop"
-	case AutoNext:
-		return "next"
-	case AutoShuffle:
-		return "shuffle"
-	default:
+	if a < 0 || int(a) >= len(autoModeNames) {
 		return "?unknown?"
 	}
+	return autoModeNames[a]
 }
 
 // ParseAutoMode tries to parse an AutoMode from a string.
 func ParseAutoMode(s string) (AutoMode, error) {
-	switch s {
-	case "off":
-		return AutoOff, nil
-	case "drop":
-		return AutoDrop, nil
-	case "next":
-		return AutoNext, nil
-	case "shuffle":
-		return AutoShuffle, nil
-	default:
-		return AutoOff, fmt.Errorf("invalid automode")
+	for i, n := range autoModeNames {
+		if s == n {
+			return AutoMode(i), nil
+		}
+	}
+	return AutoOff, fmt.Errorf("invalid automode")
+}
+
+// ArgAutoMode gets the index-th argument of m as an AutoMode.
+// Like the typed accessors on Message, it returns a message.ArgError if the argument is missing or invalid.
+func ArgAutoMode(m *message.Message, index int) (AutoMode, error) {
+	s, err := m.ArgEnum(index, autoModeNames...)
+	if err != nil {
+		return AutoOff, err
 	}
+	return ParseAutoMode(s)
 }
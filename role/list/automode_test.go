@@ -1,9 +1,11 @@
 package list_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
+	"github.com/UniversityRadioYork/bifrost-go/message"
 	"github.com/UniversityRadioYork/bifrost-go/role/list"
 )
 
@@ -100,3 +102,18 @@ func TestAutoModeParseIdempotence(t *testing.T) {
 		}
 	}
 }
+
+// TestArgAutoMode checks ArgAutoMode on valid, invalid, and missing arguments.
+func TestArgAutoMode(t *testing.T) {
+	m := message.New("x", "AUTO").AddArgs("shuffle", "sideways")
+
+	if a, err := list.ArgAutoMode(m, 0); err != nil || a != list.AutoShuffle {
+		t.Errorf("ArgAutoMode(0) = %v, %v; want shuffle", a, err)
+	}
+	for _, i := range []int{1, 2} {
+		var aerr message.ArgError
+		if _, err := list.ArgAutoMode(m, i); !errors.As(err, &aerr) {
+			t.Errorf("ArgAutoMode(%d) error %v; want ArgError", i, err)
+		}
+	}
+}
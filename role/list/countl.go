@@ -1,8 +1,6 @@
 package list
 
 import (
-	"strconv"
-
 	"github.com/UniversityRadioYork/bifrost-go/core"
 	"github.com/UniversityRadioYork/bifrost-go/message"
 )
@@ -15,7 +13,7 @@ type CountLResponse int
 
 // Message converts CountLResponse c to a message with tag tag.
 func (c CountLResponse) Message(tag string) *message.Message {
-	return message.New(tag, RsCountL).AddArgs(strconv.Itoa(int(c)))
+	return message.New(tag, RsCountL).AddInt(int(c))
 }
 
 // ParseCountLResponse tries to parse an arbitrary message as a COUNTL response.
@@ -25,12 +23,11 @@ func ParseCountLResponse(m *message.Message) (CountLResponse, error) {
 		return 0, err
 	}
 
-	var cstr string
-	if cstr, err = core.OneArg(m); err != nil {
+	if _, err = core.OneArg(m); err != nil {
 		return 0, err
 	}
 
 	var cint int
-	cint, err = strconv.Atoi(cstr)
+	cint, err = m.ArgInt(0)
 	return CountLResponse(cint), err
 }